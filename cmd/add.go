@@ -1,44 +1,51 @@
 package cmd
 
 import (
-	"fmt"
 	"io"
 
 	"cmdctl/cmd/templates"
 	cmdutil "cmdctl/cmd/util"
 	"cmdctl/model"
 	"cmdctl/pkg/i18n"
+	"cmdctl/pkg/validation"
 
 	"github.com/spf13/cobra"
 )
 
 type CreateOptions struct {
-	username string
-	password string
-	email    string
+	username   string
+	password   string
+	email      string
+	attributes model.Attributes
 }
 
 var (
 	addExample = templates.Examples(i18n.T(`
-		# Add a new user lkong with password
-		cmdctl add lkong lkongpasswd
+		# Add a new user lkong, the password is prompted for
+		cmdctl add lkong
 
 		# Add a new user lkong with email
-		cmdctl add lkong lkongpasswd -e 466701708@qq.com`))
+		cmdctl add lkong -e 466701708@qq.com
+
+		# Add a new user lkong with custom attributes
+		cmdctl add lkong --attr team=infra --attr tier=oncall
+
+		# Add a new user lkong with the password read from stdin
+		cat passwd.txt | cmdctl add lkong --password-stdin`))
 )
 
-func NewCmdAdd(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+func NewCmdAdd(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "add USERNAME PASSWORD",
+		Use:     "add USERNAME [PASSWORD]",
 		Short:   i18n.T("Add a user"),
-		Long:    "Add a user",
+		Long:    "Add a user, the password is prompted for if omitted. Avoid giving the password as an argument, it is kept in the shell history",
 		Example: addExample,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(validateCreateArgs(cmd, args))
 			options := new(CreateOptions)
-			cmdutil.CheckErr(options.Complete(cmd))
+			cmdutil.CheckErr(options.Complete(cmd, in, cmdErr, args))
 			if err := options.Validate(); err != nil {
-				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, err.Error()))
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "%s", err))
 			}
 			cmdutil.CheckErr(options.RunAdd(f, out, cmdErr, args))
 			return
@@ -47,49 +54,67 @@ func NewCmdAdd(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Comman
 	}
 
 	cmd.Flags().StringP("email", "e", "", "Specify the user email.")
-	return cmd
+	cmd.Flags().StringArray("attr", []string{}, "Set a custom attribute as key=value, can be repeated.")
+	addPasswordStdinFlag(cmd)
+	cmdutil.SetSecretArgs(cmd, 1)
+	return cmdutil.SetPermission(cmd, PermissionUserCreate)
 }
 
 func validateCreateArgs(cmd *cobra.Command, args []string) error {
-	if len(args) < 2 {
+	if len(args) != 1 && len(args) != 2 {
 		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
 	}
-
-	if !isUsername(args[0]) {
-		return fmt.Errorf("%s is not a legal user name", args[0])
+	if len(args) == 2 && cmdutil.GetFlagBool(cmd, "password-stdin") {
+		return cmdutil.UsageErrorf(cmd, "--password-stdin can not be used with the PASSWORD argument")
 	}
 
 	return nil
 }
 
 func (o *CreateOptions) RunAdd(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, args []string) error {
-	user := model.UserModel{
-		Username: args[0],
-		Password: args[1],
-		Email:    o.email,
-	}
+	user := o.user()
 
-	db := model.GetSelfDB()
-	defer db.Close()
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
 
-	if err := db.Create(&user).Error; err != nil {
+	if err := store.Create(user); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (o *CreateOptions) Complete(cmd *cobra.Command) error {
+func (o *CreateOptions) Complete(cmd *cobra.Command, in io.Reader, cmdErr io.Writer, args []string) error {
+	o.username = args[0]
 	o.email = cmdutil.GetFlagString(cmd, "email")
-	return nil
+
+	var err error
+	if o.attributes, err = parseAttributes(cmdutil.GetFlagStringArray(cmd, "attr")); err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	if len(args) == 2 {
+		o.password = args[1]
+		return nil
+	}
+
+	o.password, err = readPassword(cmd, in, cmdErr, true)
+	return err
 }
 
+// Validate checks the user against the rules of model.UserModel.
 func (o *CreateOptions) Validate() error {
-	if o.email != "" {
-		if !isEmail(o.email) {
-			return fmt.Errorf("%s is not a email format", o.email)
-		}
-	}
+	return validation.Validate(o.user())
+}
 
-	return nil
+func (o *CreateOptions) user() *model.UserModel {
+	return &model.UserModel{
+		Username:   o.username,
+		Password:   o.password,
+		Email:      o.email,
+		Attributes: o.attributes,
+	}
 }
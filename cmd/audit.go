@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/homedir"
+	"cmdctl/pkg/i18n"
+	"cmdctl/util"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdAudit(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit SUBCOMMAND",
+		Short: i18n.T("Query the audit log of mutating commands"),
+		Long:  "Query the audit log of mutating commands",
+		Run: func(cmd *cobra.Command, args []string) {
+			// run sub command
+			defaultRunFunc := cmdutil.DefaultSubCommandRun(out)
+			defaultRunFunc(cmd, args)
+			return
+		},
+		Aliases: []string{},
+	}
+
+	// sub command
+	cmd.AddCommand(NewCmdAuditList(f, out, cmdErr))
+
+	return cmd
+}
+
+// auditFile is the local JSON lines file used when the audit records can not
+// be saved to the database.
+func auditFile() string {
+	return filepath.Join(homedir.HomeDir(), RecommendedHomeDir, "audit.jsonl")
+}
+
+// beginAudit starts recording the invocation of cmd if it is a mutating command,
+// the record is saved with the exit code by an exit hook.
+func beginAudit(f cmdutil.Factory, cmd *cobra.Command) {
+	if cmdutil.GetPermission(cmd) == "" {
+		return
+	}
+
+	record := &model.AuditModel{
+		Command:   cmd.CommandPath(),
+		Args:      f.Command(cmd),
+		Username:  actingUser(),
+		Host:      util.GetLocalAddress(),
+		StartedAt: time.Now(),
+	}
+
+	cmdutil.OnExit(func(code int) {
+		record.FinishedAt = time.Now()
+		record.ExitCode = code
+		saveAudit(record)
+	})
+}
+
+// saveAudit saves the record to the database, or to the local audit file if
+// the database is not available.
+func saveAudit(record *model.AuditModel) {
+	db, err := model.Get(model.SelfDataSource)
+	if err == nil {
+		if err = model.CreateAudit(db, record); err == nil {
+			return
+		}
+	}
+
+	glog.V(2).Infof("Save audit record to database failed, fall back to %s: %v", auditFile(), err)
+	if err := appendLocalAudit(record); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: save audit record failed: %v\n", err)
+	}
+}
+
+func appendLocalAudit(record *model.AuditModel) error {
+	if err := os.MkdirAll(filepath.Dir(auditFile()), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(auditFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(record)
+}
+
+// readLocalAudits reads the records matching opts from the local audit file, the newest first.
+func readLocalAudits(opts *model.ListAuditOptions) ([]*model.AuditModel, error) {
+	audits := make([]*model.AuditModel, 0)
+	file, err := os.Open(auditFile())
+	if os.IsNotExist(err) {
+		return audits, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		record := &model.AuditModel{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", auditFile(), line, err)
+		}
+		if opts.Match(record) {
+			audits = append(audits, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// reverse to the newest first and apply the limit
+	for i, j := 0, len(audits)-1; i < j; i, j = i+1, j-1 {
+		audits[i], audits[j] = audits[j], audits[i]
+	}
+	if opts.Limit > 0 && len(audits) > opts.Limit {
+		audits = audits[:opts.Limit]
+	}
+	return audits, nil
+}
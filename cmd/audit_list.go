@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+type AuditListOptions struct {
+	model.ListAuditOptions
+
+	local        bool
+	output       string
+	tableOptions cmdutil.TableOptions
+}
+
+var (
+	auditListExample = templates.Examples(i18n.T(`
+	# List the latest 50 audit records
+	cmdctl audit list
+
+	# List the commands run by lkong in the last 7 days
+	cmdctl audit list --user lkong --since 7d
+
+	# List the user deletions since a date
+	cmdctl audit list --command delete --since 2018-06-01
+
+	# List the records saved locally when the database was not available
+	cmdctl audit list --local
+
+	# List the records as json, or print the command lines only
+	cmdctl audit list -o json
+	cmdctl audit list --columns command --no-headers`))
+)
+
+func NewCmdAuditList(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   i18n.T("List the audit records"),
+		Long:    "List the audit records, the newest first",
+		Example: auditListExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options := new(AuditListOptions)
+			if err := options.Complete(cmd); err != nil {
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "%s", err))
+			}
+			cmdutil.CheckErr(options.Run(f, out, cmdErr, args))
+			return
+		},
+		Aliases: []string{"li"},
+	}
+
+	cmd.Flags().String("user", "", "Only list the commands run by the user.")
+	cmd.Flags().String("since", "", "Only list the commands run after a duration ago (e.g. 24h, 7d) or a time (e.g. 2018-06-01, RFC3339).")
+	cmd.Flags().String("command", "", "Only list the commands whose path contains the string, e.g. 'user import'.")
+	cmd.Flags().Int("limit", 50, "The max number of records to list, 0 means no limit.")
+	cmd.Flags().Bool("local", false, "List the records in the local audit file instead of the database.")
+	cmdutil.AddOutputFlag(cmd, "")
+	cmdutil.AddTableFlags(cmd, true)
+	return cmd
+}
+
+func (o *AuditListOptions) Run(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, args []string) error {
+	printer, err := cmdutil.NewPrinter(o.output, o.tableOptions)
+	if err != nil {
+		return err
+	}
+
+	var audits []*model.AuditModel
+	if o.local {
+		audits, err = readLocalAudits(&o.ListAuditOptions)
+	} else {
+		if err = model.DB.Init(); err == nil {
+			audits, err = model.ListAudits(&o.ListAuditOptions)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := make([]cmdutil.Row, 0, len(audits))
+	for _, a := range audits {
+		// the local records have no id
+		name := strconv.FormatUint(a.Id, 10)
+		if a.Id == 0 {
+			name = a.StartedAt.Format(time.RFC3339Nano)
+		}
+		rows = append(rows, cmdutil.Row{
+			Name: name,
+			Cells: []string{
+				a.StartedAt.Format("2006-01-02 15:04:05"),
+				a.Username,
+				a.Host,
+				strconv.Itoa(a.ExitCode),
+				a.FinishedAt.Sub(a.StartedAt).Round(time.Millisecond).String(),
+				a.Args,
+			},
+		})
+	}
+
+	return printer.PrintObj(&cmdutil.Printable{
+		Kind:   "audit",
+		Object: audits,
+		Columns: []cmdutil.Column{
+			{Name: "Started"},
+			{Name: "User"},
+			{Name: "Host"},
+			{Name: "Exit", Color: func(v string) string {
+				if v != "0" {
+					return color.RedString(v)
+				}
+				return v
+			}},
+			{Name: "Duration"},
+			{Name: "Command"},
+		},
+		Rows: rows,
+	}, out)
+}
+
+func (o *AuditListOptions) Complete(cmd *cobra.Command) error {
+	o.Username = cmdutil.GetFlagString(cmd, "user")
+	o.Command = cmdutil.GetFlagString(cmd, "command")
+	o.Limit = cmdutil.GetFlagInt(cmd, "limit")
+	o.local = cmdutil.GetFlagBool(cmd, "local")
+	o.output = cmdutil.OutputFormat(cmd)
+	o.tableOptions = cmdutil.TableOptionsForCommand(cmd)
+	if _, err := cmdutil.NewPrinter(o.output, o.tableOptions); err != nil {
+		return err
+	}
+
+	if since := cmdutil.GetFlagString(cmd, "since"); since != "" {
+		t, err := parseSince(since)
+		if err != nil {
+			return err
+		}
+		o.Since = t
+	}
+	return nil
+}
+
+// parseSince parses a duration ago, such as 7d, or a point in time.
+func parseSince(s string) (time.Time, error) {
+	if d, err := parseAge(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, must be a duration such as 7d or a time such as 2018-06-01", s)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/homedir"
+
+	"github.com/spf13/cobra"
+)
+
+// Permissions required by the mutating commands, see cmdutil.SetPermission.
+const (
+	PermissionUserCreate     = "user:create"
+	PermissionUserUpdate     = "user:update"
+	PermissionUserDelete     = "user:delete"
+	PermissionUserImport     = "user:import"
+	PermissionUserRestore    = "user:restore"
+	PermissionUserPurge      = "user:purge"
+	PermissionDBInit         = "db:init"
+	PermissionDBMigrate      = "db:migrate"
+	PermissionDBDump         = "db:dump"
+	PermissionDBRestore      = "db:restore"
+	PermissionTemplateImport = "template:import"
+	PermissionRoleManage     = "role:manage"
+	PermissionSecretManage   = "secret:manage"
+	PermissionConfigWrite    = "config:write"
+)
+
+// loginFile stores the name of the user logged in by `cmdctl login`.
+func loginFile() string {
+	return filepath.Join(homedir.HomeDir(), RecommendedHomeDir, "login")
+}
+
+// untrustedAuthUser warns once that auth.user is ignored.
+var untrustedAuthUser sync.Once
+
+// actingUser returns the user running cmdctl, `auth.user` in the config
+// takes precedence over the user logged in by `cmdctl login`. It is ignored
+// unless set by the system, user or --config file, so that a project file or
+// CMDCTL_AUTH_USER can not act as another user.
+func actingUser() string {
+	if user := config.Get().Auth.User; user != "" {
+		trusted, err := config.TrustedOrigin("auth.user")
+		if err == nil && trusted {
+			return user
+		}
+		untrustedAuthUser.Do(func() {
+			fmt.Fprintf(os.Stderr, "warning: auth.user is ignored, it is only honoured in the system, user or --config file\n")
+		})
+	}
+
+	data, err := ioutil.ReadFile(loginFile())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func saveLogin(username string) error {
+	if err := os.MkdirAll(filepath.Dir(loginFile()), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(loginFile(), []byte(username+"\n"), 0600)
+}
+
+// bootstrapCommands create the schema, they are not authorized as long as
+// the tables of the roles do not exist, see schemaMissing.
+var bootstrapCommands = map[string]bool{
+	"cmdctl init":       true,
+	"cmdctl migrate up": true,
+}
+
+// schemaMissing reports whether the tables of the roles do not exist yet,
+// which is also the case if the database of the self datasource has not
+// been created.
+func schemaMissing() bool {
+	db, err := model.GetSelfDB()
+	if err != nil {
+		return true
+	}
+	return !model.RoleTablesExist(db)
+}
+
+// authorize checks whether the acting user has the permission required by cmd,
+// it is enforced only if `rbac.enabled` is true. The commands creating the
+// schema are allowed until it exists, since the permissions are stored in it.
+func authorize(cmd *cobra.Command) error {
+	permission := cmdutil.GetPermission(cmd)
+	if permission == "" || !config.Get().RBAC.Enabled {
+		return nil
+	}
+	if bootstrapCommands[cmd.CommandPath()] && schemaMissing() {
+		return nil
+	}
+
+	user := actingUser()
+	if user == "" {
+		return &cmdutil.PermissionDeniedError{Permission: permission}
+	}
+
+	if err := model.DB.Init(); err != nil {
+		return err
+	}
+
+	ok, err := model.HasPermission(user, permission)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &cmdutil.PermissionDeniedError{User: user, Permission: permission}
+	}
+
+	return nil
+}
@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
+	"os"
 	"path/filepath"
-	"strings"
 
 	"cmdctl/cmd/templates"
 	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
 	"cmdctl/pkg/homedir"
 
 	"github.com/spf13/cobra"
@@ -15,7 +17,7 @@ import (
 
 const (
 	bashCompletionFunc = `# call cmdctl get $1,
-__cmdctl_override_flag_list=(gconfig cluster user context namespace server)
+__cmdctl_override_flag_list=(config context server database credential)
 __cmdctl_override_flags()
 {
     local ${__cmdctl_override_flag_list[*]} two_word_of of
@@ -57,20 +59,25 @@ __cmdctl_get_namespaces()
 
 __cmdctl_config_get_contexts()
 {
-    __cmdctl_parse_config "contexts"
+    __cmdctl_config_get "contexts"
 }
 
-__cmdctl_config_get_clusters()
+__cmdctl_config_get_servers()
 {
-    __cmdctl_parse_config "clusters"
+    __cmdctl_config_get "servers"
 }
 
-__cmdctl_config_get_users()
+__cmdctl_config_get_databases()
 {
-    __cmdctl_parse_config "users"
+    __cmdctl_config_get "databases"
 }
 
-# $1 has to be "contexts", "clusters" or "users"
+__cmdctl_config_get_credentials()
+{
+    __cmdctl_config_get "credentials"
+}
+
+# $1 has to be "contexts", "servers", "databases" or "credentials"
 __cmdctl_config_get()
 {
     local template cmdctl_out
@@ -83,7 +90,7 @@ __cmdctl_config_get()
 __cmdctl_parse_get()
 {
     local template
-    template="{{ range .items  }}{{ .metadata.name }} {{ end }}"
+    template="{{ range . }}{{ .username }} {{ end }}"
     local cmdctl_out
     if cmdctl_out=$(cmdctl get $(__cmdctl_override_flags) -o template --template="${template}" "$1" 2>/dev/null); then
         COMPREPLY=( $( compgen -W "${cmdctl_out[*]}" -- "$cur" ) )
@@ -165,7 +172,7 @@ __custom_func() {
             __cmdctl_get_resource_node
             return
             ;;
-        cmdctl_config_use-context)
+        cmdctl_config_use-context | cmdctl_config_set-context | cmdctl_config_delete-context)
             __cmdctl_config_get_contexts
             return
             ;;
@@ -182,13 +189,20 @@ var cfgFile string
 
 var (
 	bash_completion_flags = map[string]string{
-		"namespace": "__cmdctl_get_namespaces",
-		"context":   "__cmdctl_config_get_contexts",
-		"cluster":   "__cmdctl_config_get_clusters",
-		"user":      "__cmdctl_config_get_users",
+		"namespace":  "__cmdctl_get_namespaces",
+		"context":    "__cmdctl_config_get_contexts",
+		"server":     "__cmdctl_config_get_servers",
+		"database":   "__cmdctl_config_get_databases",
+		"credential": "__cmdctl_config_get_credentials",
 	}
 )
 
+// overrides are given by the global flags added by addOverrideFlags.
+var overrides config.Overrides
+
+// configErr is the error of unmarshalling the config, see checkConfig.
+var configErr error
+
 func NewCommand(f cmdutil.Factory, in io.Reader, out, err io.Writer) *cobra.Command {
 	// Parent command to which all subcommands are added.
 	cmds := &cobra.Command{
@@ -197,6 +211,12 @@ func NewCommand(f cmdutil.Factory, in io.Reader, out, err io.Writer) *cobra.Comm
 		Long: templates.LongDesc(`
 		Microctl is a toolkit for microservice development. It helps you build future-proof application platforms and services..`),
 		Run: runHelp,
+		// audit and check the permissions of mutating commands, see cmdutil.SetPermission
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(checkConfig(cmd))
+			beginAudit(f, cmd)
+			cmdutil.CheckErr(authorize(cmd))
+		},
 		BashCompletionFunction: bashCompletionFunc,
 	}
 
@@ -212,8 +232,15 @@ func NewCommand(f cmdutil.Factory, in io.Reader, out, err io.Writer) *cobra.Comm
 			Message: "User Control Commands:",
 			Commands: []*cobra.Command{
 				NewCmdInit(),
-				NewCmdAdd(f, out, err),
+				NewCmdAdd(f, in, out, err),
 				NewCmdList(f, out, err),
+				NewCmdGet(f, out, err),
+				NewCmdDescribe(f, out, err),
+				NewCmdUpdate(f, in, out, err),
+				NewCmdDelete(f, in, out, err),
+				NewCmdRehash(f, out, err),
+				NewCmdLogin(f, in, out, err),
+				NewCmdLogout(f, out, err),
 			},
 		},
 		{
@@ -232,14 +259,22 @@ func NewCommand(f cmdutil.Factory, in io.Reader, out, err io.Writer) *cobra.Comm
 			Message: "Commands Have Sub Commands:",
 			Commands: []*cobra.Command{
 				NewCmdTemplate(f, out, err),
+				NewCmdMigrate(f, out, err),
+				NewCmdDB(f, out, err),
+				NewCmdUser(f, out, err),
+				NewCmdRole(f, out, err),
+				NewCmdAudit(f, out, err),
+				NewCmdConfig(f, out, err),
+				NewCmdSecret(f, in, out, err),
 			},
 		},
 	}
 	groups.Add(cmds)
 	templates.ActsAsRootCommand(cmds, []string{}, groups...)
 
-	cmds.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is ./sreconfig.yaml)")
+	cmds.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file to use instead of /etc/cmdctl/cmdctl.yaml, ~/.cmdctl/cmdctl.yaml and the cmdctl.yaml of the current directory or its parents")
 	cmds.PersistentFlags().BoolP("debug", "", false, "enable the debug mode")
+	addOverrideFlags(cmds)
 	cobra.OnInitialize(initConfig)
 
 	cmds.AddCommand(NewCmdVersion(out))
@@ -254,21 +289,58 @@ func runHelp(cmd *cobra.Command, args []string) {
 	cmd.Help()
 }
 
-// initConfig reads in config file and ENV variables if set.
+// initConfig reads in the config files and ENV variables if set, see
+// config.ReadInConfig for the files and their precedence.
 func initConfig() {
-	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		viper.AddConfigPath(".")
-		viper.AddConfigPath(filepath.Join(homedir.HomeDir(), RecommendedHomeDir))
-		viper.SetConfigName("cmdctl")
-	}
-
 	viper.SetConfigType("yaml")
 	viper.AutomaticEnv() // read in environment variables that match
-	viper.SetEnvPrefix("CMDCTL")
-	replacer := strings.NewReplacer(".", "_")
-	viper.SetEnvKeyReplacer(replacer)
-	viper.ReadInConfig()
+	viper.SetEnvPrefix(config.EnvPrefix)
+	viper.SetEnvKeyReplacer(config.EnvKeyReplacer)
+	config.SetDefaults()
+
+	configErr = config.ReadInConfig(cfgFile, userConfigFile())
+	if err := config.Init(); configErr == nil {
+		configErr = err
+	}
+	config.SetOverrides(overrides)
+
+	for _, warning := range config.CheckPermissions() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+}
+
+// userConfigFile returns the config file of the user, ~/.cmdctl/cmdctl.yaml.
+func userConfigFile() string {
+	return filepath.Join(homedir.HomeDir(), RecommendedHomeDir, config.FileName)
+}
+
+// addOverrideFlags adds the global flags which override the current context.
+func addOverrideFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&overrides.Context, "context", "", "The name of the config context to use, instead of current-context.")
+	flags.StringVar(&overrides.Server, "server", "", "The name of the config server to use, instead of the one of the context.")
+	flags.StringVar(&overrides.Database, "database", "", "The name of the config database to use, instead of the one of the context.")
+	flags.StringVar(&overrides.Credential, "credential", "", "The name of the config credential to use, instead of the one of the context.")
+
+	for name, completion := range bash_completion_flags {
+		if flags.Lookup(name) != nil {
+			flags.SetAnnotation(name, cobra.BashCompCustom, []string{completion})
+		}
+	}
+}
+
+// checkConfig reports the errors of the config and of the current context,
+// except for the config commands, which are used to fix them.
+func checkConfig(cmd *cobra.Command) error {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "config" && c.HasParent() && !c.Parent().HasParent() {
+			return nil
+		}
+	}
+
+	if configErr != nil {
+		return fmt.Errorf("%v, run 'cmdctl config validate' to see the problems", configErr)
+	}
+	_, err := config.CurrentContext()
+	return err
 }
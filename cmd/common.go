@@ -1,20 +1,91 @@
 package cmd
 
 import (
-	"regexp"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/asaskevich/govalidator"
-)
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/duration"
+	"cmdctl/pkg/selector"
 
-const (
-	TABLE_WIDTH = 80
+	"github.com/spf13/cobra"
 )
 
-func isUsername(username string) bool {
-	reg := regexp.MustCompile("^[a-zA-Z]+$")
-	return reg.MatchString(username)
+// validateUserResource checks the resource type given to get, describe, update and delete,
+// only users are supported now.
+func validateUserResource(cmd *cobra.Command, resource string) error {
+	switch resource {
+	case "user", "users", "u":
+		return nil
+	}
+
+	return cmdutil.UsageErrorf(cmd, "unknown resource type %q, only 'user' is supported", resource)
+}
+
+// translateTimestamp returns the elapsed time since timestamp in
+// human-readable approximation.
+func translateTimestamp(timestamp time.Time) string {
+	if timestamp.IsZero() {
+		return "<unknown>"
+	}
+
+	return duration.HumanDuration(time.Since(timestamp))
+}
+
+// parseAge parses a duration like time.ParseDuration, a number of days
+// such as "30d" is accepted as well.
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// parseAttributes parses the `key=value` pairs given by --attr.
+func parseAttributes(pairs []string) (model.Attributes, error) {
+	attrs := make(model.Attributes, len(pairs))
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid attribute %q, must be key=value", pair)
+		}
+		key, value := pair[:i], pair[i+1:]
+		if err := selector.ValidateKey(key); err != nil {
+			return nil, err
+		}
+		attrs[key] = value
+	}
+	return attrs, nil
 }
 
-func isEmail(email string) bool {
-	return govalidator.IsEmail(email)
+// formatAttributes formats the attributes as sorted key=value pairs, or <none>.
+func formatAttributes(attrs model.Attributes) string {
+	if len(attrs) == 0 {
+		return "<none>"
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+attrs[k])
+	}
+	return strings.Join(pairs, ",")
 }
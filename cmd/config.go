@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"io"
+
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdConfig(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config SUBCOMMAND",
+		Short: i18n.T("Modify the cmdctl config file"),
+		Long:  "Modify the cmdctl config file, such as setting the db and fileserver settings or switching between the contexts which pick a server, a database and a credential",
+		Run: func(cmd *cobra.Command, args []string) {
+			// run sub command
+			defaultRunFunc := cmdutil.DefaultSubCommandRun(out)
+			defaultRunFunc(cmd, args)
+			return
+		},
+	}
+
+	// sub command
+	cmd.AddCommand(NewCmdConfigView(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigGetContexts(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigUseContext(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigSetContext(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigDeleteContext(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigGet(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigSet(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigUnset(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigEdit(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigPath(f, out, cmdErr))
+	cmd.AddCommand(NewCmdConfigValidate(f, out, cmdErr))
+
+	return cmd
+}
+
+// configFile returns the config file modified by the config commands: the
+// file given by --config, or else the project file, or else the user file.
+func configFile() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	if project := config.ProjectFile(userConfigFile()); project != "" {
+		return project
+	}
+	return userConfigFile()
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configDeleteContextExample = templates.Examples(i18n.T(`
+	# Delete the context dev
+	cmdctl config delete-context dev`))
+)
+
+func NewCmdConfigDeleteContext(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "delete-context NAME",
+		Short:   i18n.T("Delete the specified context from the config file"),
+		Long:    "Delete the specified context from the config file",
+		Example: configDeleteContextExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigDeleteContext(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionConfigWrite)
+}
+
+func RunConfigDeleteContext(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	name := args[0]
+
+	file, err := config.LoadFile(configFile())
+	if err != nil {
+		return err
+	}
+	if !file.DeleteContext(name) {
+		return fmt.Errorf("cannot delete context %s, not in %s", name, file.Path)
+	}
+	if err := file.Save(); err != nil {
+		return err
+	}
+
+	if c, err := config.Load(); err == nil && c.CurrentContext == name {
+		fmt.Fprintf(cmdErr, "warning: this removed your active context, use \"cmdctl config use-context\" to select a different one\n")
+	}
+	fmt.Fprintf(out, "deleted context %s from %s\n", name, file.Path)
+	return nil
+}
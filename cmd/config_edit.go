@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configEditExample = templates.Examples(i18n.T(`
+	# Edit the config file with $EDITOR
+	cmdctl config edit
+
+	# Edit the config file with vim
+	EDITOR=vim cmdctl config edit`))
+)
+
+func NewCmdConfigEdit(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "edit",
+		Short:   i18n.T("Edit the config file"),
+		Long:    "Edit a copy of the config file with $VISUAL or $EDITOR, the file is replaced only if the copy passes the checks of config validate, the editor is opened again with the problems otherwise",
+		Example: configEditExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigEdit(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionConfigWrite)
+}
+
+func RunConfigEdit(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	path := configFile()
+	original, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile("", "cmdctl-edit-*.yaml")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	// the problems are listed at the top of the copy and the editor is opened
+	// again, until the copy is valid or saved without changes
+	var rejected []byte
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+
+		data, err := ioutil.ReadFile(tmp.Name())
+		if err != nil {
+			return err
+		}
+		edited := stripEditProblems(data)
+		if bytes.Equal(edited, original) {
+			os.Remove(tmp.Name())
+			fmt.Fprintln(out, "Edit cancelled, no changes made.")
+			return nil
+		}
+
+		problems, err := config.ValidateContent(editValidatePaths(path), map[string][]byte{path: edited})
+		if err != nil {
+			return err
+		}
+		problems = problemsOf(problems, path)
+		if len(problems) == 0 {
+			os.Remove(tmp.Name())
+			if err := config.WriteFileAtomic(path, edited); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%s edited.\n", path)
+			return nil
+		}
+		if rejected != nil && bytes.Equal(edited, rejected) {
+			return fmt.Errorf("the edited config has %d problem(s), %s is unchanged and the changes are kept in %s", len(problems), path, tmp.Name())
+		}
+
+		rejected = edited
+		if err := ioutil.WriteFile(tmp.Name(), append(editProblemsHeader(problems), edited...), 0600); err != nil {
+			return err
+		}
+	}
+}
+
+// editProblemPrefix starts the comment lines listing the problems of the
+// edited config, they are removed before the copy is checked again.
+const editProblemPrefix = "# cmdctl: "
+
+// editProblemsHeader lists the problems as comments, the lines are those of
+// the config below the comments.
+func editProblemsHeader(problems []*config.Problem) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(editProblemPrefix + "fix the problems below, or save the file unchanged to cancel the edit\n")
+	for _, p := range problems {
+		line := fmt.Sprintf("line %d: %s", p.Line, p.Message)
+		if p.Key != "" {
+			line = fmt.Sprintf("line %d: %s: %s", p.Line, p.Key, p.Message)
+		}
+		if p.Hint != "" {
+			line += " (" + p.Hint + ")"
+		}
+		buf.WriteString(editProblemPrefix + line + "\n")
+	}
+	return buf.Bytes()
+}
+
+// stripEditProblems removes the comments added by editProblemsHeader.
+func stripEditProblems(data []byte) []byte {
+	for bytes.HasPrefix(data, []byte(editProblemPrefix)) {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return nil
+		}
+		data = data[i+1:]
+	}
+	return data
+}
+
+// editValidatePaths returns the config files the edited file is checked
+// with, the contexts may refer to the entries of the other files read.
+func editValidatePaths(path string) []string {
+	var paths []string
+	found := false
+	for _, s := range config.Sources() {
+		if sameFile(s.Path, path) {
+			s.Path, found = path, true
+		}
+		paths = append(paths, s.Path)
+	}
+	if !found {
+		paths = []string{path}
+	}
+	return paths
+}
+
+// problemsOf returns the problems of the file, those of the other files do
+// not prevent the edit.
+func problemsOf(problems []*config.Problem, path string) []*config.Problem {
+	result := make([]*config.Problem, 0, len(problems))
+	for _, p := range problems {
+		if p.File == path {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// runEditor opens the file with $VISUAL or $EDITOR, which may have arguments
+// such as "code --wait".
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	fields := strings.Fields(editor)
+	command := exec.Command(fields[0], append(fields[1:], path)...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %v", editor, err)
+	}
+	return nil
+}
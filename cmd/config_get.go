@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	configGetExample = templates.Examples(i18n.T(`
+	# Print the address of the database
+	cmdctl config get db.addr
+
+	# Print the whole fileserver section
+	cmdctl config get fileserver`))
+)
+
+func NewCmdConfigGet(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "get KEY",
+		Short:   i18n.T("Print the value of a setting"),
+		Long:    "Print the value of a setting, the environment variables such as CMDCTL_DB_ADDR take precedence over the config file",
+		Example: configGetExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigGet(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmd
+}
+
+func RunConfigGet(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	key := args[0]
+	if !viper.IsSet(key) {
+		return fmt.Errorf("%s is not set", key)
+	}
+
+	switch value := normalizeConfig(viper.Get(key)).(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	default:
+		_, err := fmt.Fprintln(out, value)
+		return err
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configGetContextsExample = templates.Examples(i18n.T(`
+	# List all the contexts, the current one is marked by *
+	cmdctl config get-contexts
+
+	# Describe the context dev
+	cmdctl config get-contexts dev`))
+)
+
+func NewCmdConfigGetContexts(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "get-contexts [NAME...]",
+		Short:   i18n.T("Describe one or many contexts"),
+		Long:    "Describe one or many contexts of the config",
+		Example: configGetContextsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigGetContexts(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmdutil.AddOutputFlag(cmd, "")
+	cmdutil.AddTableFlags(cmd, true)
+	return cmd
+}
+
+func RunConfigGetContexts(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	printer, err := cmdutil.PrinterForCommand(cmd)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	c, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	contexts := c.Contexts
+	if len(args) > 0 {
+		contexts = make([]config.NamedContext, 0, len(args))
+		for _, name := range args {
+			context := c.Context(name)
+			if context == nil {
+				return fmt.Errorf("context %q not found", name)
+			}
+			contexts = append(contexts, config.NamedContext{Name: name, Context: *context})
+		}
+	}
+
+	current := c.CurrentContext
+	if o := config.GetOverrides(); o.Context != "" {
+		current = o.Context
+	}
+
+	rows := make([]cmdutil.Row, 0, len(contexts))
+	for _, context := range contexts {
+		mark := ""
+		if context.Name == current {
+			mark = "*"
+		}
+		rows = append(rows, cmdutil.Row{
+			Name:  context.Name,
+			Cells: []string{mark, context.Name, context.Context.Server, context.Context.Database, context.Context.Credential},
+		})
+	}
+
+	return printer.PrintObj(&cmdutil.Printable{
+		Kind:   "context",
+		Object: contexts,
+		Columns: []cmdutil.Column{
+			{Name: "Current"},
+			{Name: "Name"},
+			{Name: "Server"},
+			{Name: "Database"},
+			{Name: "Credential"},
+		},
+		Rows: rows,
+	}, out)
+}
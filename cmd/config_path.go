@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configPathExample = templates.Examples(i18n.T(`
+	# Print the config file modified by the config commands
+	cmdctl config path
+
+	# Print every config file read, from the lowest to the highest precedence
+	cmdctl config path --all`))
+)
+
+func NewCmdConfigPath(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "path",
+		Short:   i18n.T("Print the path of the config file"),
+		Long:    "Print the path of the config file modified by the config commands: the file given by --config, or else the cmdctl.yaml of the current directory or its parents, or else ~/.cmdctl/cmdctl.yaml",
+		Example: configPathExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigPath(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmd.Flags().Bool("all", false, "Print every config file read with its scope, from the lowest to the highest precedence: system, user, project, and the included files before the file including them.")
+	return cmd
+}
+
+func RunConfigPath(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	sources := config.Sources()
+	if cmdutil.GetFlagBool(cmd, "all") {
+		w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		for _, s := range sources {
+			scope := s.Scope
+			if s.IncludedBy != "" {
+				scope += " (" + s.IncludedBy + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\n", s.Path, scope)
+		}
+		return w.Flush()
+	}
+
+	if len(sources) == 0 {
+		fmt.Fprintf(cmdErr, "No config file was read, the config commands create the file below.\n")
+	}
+	fmt.Fprintln(out, configFile())
+	return nil
+}
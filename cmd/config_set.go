@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configSetExample = templates.Examples(i18n.T(`
+	# Set the address of the database
+	cmdctl config set db.addr 10.0.0.3:3306
+
+	# Set the timeout of the http server, the value is parsed as yaml so it stays a number
+	cmdctl config set fileserver.timeout 5`))
+
+	configUnsetExample = templates.Examples(i18n.T(`
+	# Remove the rbac section
+	cmdctl config unset rbac`))
+)
+
+func NewCmdConfigSet(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "set KEY VALUE",
+		Short:   i18n.T("Set a setting in the config file"),
+		Long:    "Set a setting in the config file, the comments and the order of the other settings are kept. The unknown keys and the values of the wrong type or out of range are rejected like by config validate",
+		Example: configSetExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigSet(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	// the value may be a password, such as that of db.password
+	return cmdutil.SetPermission(cmdutil.SetSecretArgs(cmd, 1), PermissionConfigWrite)
+}
+
+func NewCmdConfigUnset(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "unset KEY",
+		Short:   i18n.T("Remove a setting from the config file"),
+		Long:    "Remove a setting from the config file, the comments and the order of the other settings are kept",
+		Example: configUnsetExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigUnset(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionConfigWrite)
+}
+
+func RunConfigSet(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	// checked like config validate, so that a mistake is never written
+	if err := config.ValidateSetting(args[0], args[1]); err != nil {
+		return err
+	}
+
+	file, err := config.LoadFile(configFile())
+	if err != nil {
+		return err
+	}
+	if err := file.Set(args[0], args[1]); err != nil {
+		return err
+	}
+	if err := file.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Property %q set in %s.\n", args[0], file.Path)
+	return nil
+}
+
+func RunConfigUnset(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	file, err := config.LoadFile(configFile())
+	if err != nil {
+		return err
+	}
+	if !file.Unset(args[0]) {
+		return fmt.Errorf("%s is not set in %s", args[0], file.Path)
+	}
+	if err := file.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Property %q unset in %s.\n", args[0], file.Path)
+	return nil
+}
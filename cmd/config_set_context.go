@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configSetContextExample = templates.Examples(i18n.T(`
+	# Create the context dev, which uses the server local, the database local and the credential micro
+	cmdctl config set-context dev --server local --database local --credential micro
+
+	# Switch the current context to the database staging
+	cmdctl config set-context --current --database staging`))
+)
+
+type ConfigSetContextOptions struct {
+	name    string
+	current bool
+	context config.Context
+}
+
+func NewCmdConfigSetContext(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	o := &ConfigSetContextOptions{}
+	cmd := &cobra.Command{
+		Use:     "set-context [NAME | --current] [--server=server_name] [--database=database_name] [--credential=credential_name]",
+		Short:   i18n.T("Set a context entry in the config file"),
+		Long:    "Set a context entry in the config file, the fields which are not given are left unchanged",
+		Example: configSetContextExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(cmd, args))
+			cmdutil.CheckErr(o.Validate(cmd))
+			cmdutil.CheckErr(o.RunConfigSetContext(out))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	// these flags shadow the global override flags of the same names
+	cmd.Flags().String("server", "", "The name of the server of the context.")
+	cmd.Flags().String("database", "", "The name of the database of the context.")
+	cmd.Flags().String("credential", "", "The name of the credential of the context.")
+	cmd.Flags().Bool("current", false, "Modify the current context.")
+	return cmdutil.SetPermission(cmd, PermissionConfigWrite)
+}
+
+func (o *ConfigSetContextOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.current = cmdutil.GetFlagBool(cmd, "current")
+	o.context = config.Context{
+		Server:     cmdutil.GetFlagString(cmd, "server"),
+		Database:   cmdutil.GetFlagString(cmd, "database"),
+		Credential: cmdutil.GetFlagString(cmd, "credential"),
+	}
+
+	if len(args) > 1 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	if len(args) == 1 {
+		o.name = args[0]
+	}
+	return nil
+}
+
+func (o *ConfigSetContextOptions) Validate(cmd *cobra.Command) error {
+	if o.current == (o.name != "") {
+		return cmdutil.UsageErrorf(cmd, "either a context name or --current is required")
+	}
+	return nil
+}
+
+func (o *ConfigSetContextOptions) RunConfigSetContext(out io.Writer) error {
+	c, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	name := o.name
+	if o.current {
+		if name = c.CurrentContext; name == "" {
+			return fmt.Errorf("current-context is not set")
+		}
+	}
+
+	if o.context.Server != "" && c.Server(o.context.Server) == nil {
+		return fmt.Errorf("server %q not found", o.context.Server)
+	}
+	if o.context.Database != "" && c.Database(o.context.Database) == nil {
+		return fmt.Errorf("database %q not found", o.context.Database)
+	}
+	if o.context.Credential != "" && c.Credential(o.context.Credential) == nil {
+		return fmt.Errorf("credential %q not found", o.context.Credential)
+	}
+
+	file, err := config.LoadFile(configFile())
+	if err != nil {
+		return err
+	}
+	file.SetContext(name, o.context)
+	if err := file.Save(); err != nil {
+		return err
+	}
+
+	if c.Context(name) == nil {
+		fmt.Fprintf(out, "Context %q created.\n", name)
+	} else {
+		fmt.Fprintf(out, "Context %q modified.\n", name)
+	}
+	return nil
+}
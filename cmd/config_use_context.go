@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configUseContextExample = templates.Examples(i18n.T(`
+	# Use the context dev for the next commands
+	cmdctl config use-context dev`))
+)
+
+func NewCmdConfigUseContext(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "use-context CONTEXT_NAME",
+		Short:   i18n.T("Set the current-context in the config file"),
+		Long:    "Set the current-context in the config file",
+		Example: configUseContextExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigUseContext(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{"use"},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionConfigWrite)
+}
+
+func RunConfigUseContext(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	name := args[0]
+
+	c, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if c.Context(name) == nil {
+		return fmt.Errorf("no context exists with the name: %q", name)
+	}
+
+	file, err := config.LoadFile(configFile())
+	if err != nil {
+		return err
+	}
+	file.SetCurrentContext(name)
+	if err := file.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Switched to context %q.\n", name)
+	return nil
+}
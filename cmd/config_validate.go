@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configValidateExample = templates.Examples(i18n.T(`
+	# Check the config files read by cmdctl, including the system, project and included files
+	cmdctl config validate
+
+	# Check another config file before using it
+	cmdctl config validate ./cmdctl.yaml`))
+)
+
+func NewCmdConfigValidate(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "validate [FILE]",
+		Short:   i18n.T("Check the config files"),
+		Long:    "Check the config files read by cmdctl, or the given file, for unknown keys, values of the wrong type or out of range, and contexts referring to missing servers, databases or credentials. Every problem is printed with its line and a hint to fix it.",
+		Example: configValidateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigValidate(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmd
+}
+
+func RunConfigValidate(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args[1:])
+	}
+
+	var files []string
+	if len(args) == 1 {
+		if _, err := os.Stat(args[0]); os.IsNotExist(err) {
+			return fmt.Errorf("config file %s not found", args[0])
+		}
+		files = []string{args[0]}
+	} else {
+		for _, s := range config.Sources() {
+			files = append(files, s.Path)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no config file was read, see 'cmdctl config path --all'")
+		}
+	}
+
+	problems, err := config.ValidateFiles(files)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(out, "Config %s is valid.\n", strings.Join(files, ", "))
+		return nil
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(out, p)
+		if p.Hint != "" {
+			fmt.Fprintf(out, "    hint: %s\n", p.Hint)
+		}
+	}
+	return fmt.Errorf("found %d problem(s) in the config", len(problems))
+}
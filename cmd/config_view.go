@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	configViewExample = templates.Examples(i18n.T(`
+	# Show the config, the passwords are redacted
+	cmdctl config view
+
+	# Show the config with the passwords
+	cmdctl config view --raw
+
+	# Print the names of the contexts
+	cmdctl config view -o template --template='{{range .contexts}}{{.name}} {{end}}'
+
+	# Show where every value comes from: a default, a file and its line, an env var or a flag
+	cmdctl config view --show-origin
+
+	# Show the defaults, the config file, the env vars and the flags separately
+	cmdctl config view --merged=false
+
+	# Find the values shadowed by the env vars or the flags
+	cmdctl config view --show-origin --merged=false`))
+)
+
+// redacted replaces the passwords in config view.
+const redacted = "REDACTED"
+
+func NewCmdConfigView(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "view",
+		Short:   i18n.T("Display the config"),
+		Long:    "Display the config read by cmdctl",
+		Example: configViewExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunConfigView(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmd.Flags().Bool("raw", false, "Display the passwords instead of redacting them.")
+	cmd.Flags().Bool("show-origin", false, "Display every key with its value and its origin: default, the file and the line, the env var or the flag. The output is a table if -o is empty.")
+	cmd.Flags().Bool("merged", true, "Display the config in effect. If false, display every source separately, from the lowest to the highest precedence.")
+	cmdutil.AddOutputFlag(cmd, "Output format. One of: json|yaml|go-template=...|go-template-file=...|jsonpath=..., yaml if empty. With --show-origin, the table, wide, name, csv, tsv and custom-columns formats are supported too.")
+	return cmd
+}
+
+func RunConfigView(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	if cmdutil.GetFlagBool(cmd, "show-origin") {
+		return runConfigViewOrigins(out, cmd)
+	}
+
+	format := cmdutil.OutputFormat(cmd)
+	if format == "" {
+		format = "yaml"
+	}
+	name := strings.SplitN(format, "=", 2)[0]
+	switch name {
+	case "table", "wide", "name", "csv", "tsv", "custom-columns":
+		return cmdutil.UsageErrorf(cmd, "config view does not support -o %s", name)
+	}
+	printer, err := cmdutil.PrinterFor(format)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	if !cmdutil.GetFlagBool(cmd, "merged") {
+		layers, err := config.Layers()
+		if err != nil {
+			return err
+		}
+		sources := make([]configSource, 0, len(layers))
+		for _, layer := range layers {
+			values := normalizeConfig(layer.Values)
+			if !cmdutil.GetFlagBool(cmd, "raw") {
+				redactPasswords(values)
+			}
+			sources = append(sources, configSource{Origin: layer.Origin.String(), Config: values})
+		}
+		return printer.PrintObj(&cmdutil.Printable{Kind: "config", Object: sources}, out)
+	}
+
+	settings := normalizeConfig(viper.AllSettings())
+	if !cmdutil.GetFlagBool(cmd, "raw") {
+		redactPasswords(settings)
+	}
+	return printer.PrintObj(&cmdutil.Printable{Kind: "config", Object: settings}, out)
+}
+
+// configSource is a source of the config printed by --merged=false.
+type configSource struct {
+	Origin string      `json:"origin"`
+	Config interface{} `json:"config"`
+}
+
+// configOrigin is a setting printed by --show-origin.
+type configOrigin struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Origin string      `json:"origin"`
+	// OverriddenBy is the origin of the setting in effect instead, it is
+	// only set by --merged=false.
+	OverriddenBy string `json:"overriddenBy,omitempty"`
+}
+
+// runConfigViewOrigins prints the settings in effect with their origins, or
+// every setting of every source if --merged=false.
+func runConfigViewOrigins(out io.Writer, cmd *cobra.Command) error {
+	format := cmdutil.OutputFormat(cmd)
+	printer, err := cmdutil.PrinterFor(format)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	layers, err := config.Layers()
+	if err != nil {
+		return err
+	}
+	merged := config.Merged(layers)
+	all := !cmdutil.GetFlagBool(cmd, "merged")
+
+	settings := merged
+	if all {
+		settings = nil
+		for _, layer := range layers {
+			settings = append(settings, layer.Settings...)
+		}
+	}
+
+	raw := cmdutil.GetFlagBool(cmd, "raw")
+	origins := make([]configOrigin, 0, len(settings))
+	rows := make([]cmdutil.Row, 0, len(settings))
+	for _, s := range settings {
+		o := configOrigin{Key: s.Key, Value: normalizeConfig(s.Value), Origin: s.Origin.String()}
+		if !raw && config.IsSecretKey(s.Key) {
+			if v, ok := o.Value.(string); ok && v != "" && !config.IsSecretRef(v) {
+				o.Value = redacted
+			}
+		}
+		cells := []string{o.Key, formatConfigValue(o.Value), o.Origin}
+		if all {
+			if by, ok := config.OverriddenBy(merged, s); ok {
+				o.OverriddenBy = by.String()
+			}
+			cells = append(cells, o.OverriddenBy)
+		}
+		origins = append(origins, o)
+		rows = append(rows, cmdutil.Row{Name: o.Key, Cells: cells})
+	}
+
+	columns := []cmdutil.Column{{Name: "Key"}, {Name: "Value"}, {Name: "Origin"}}
+	if all {
+		columns = append(columns, cmdutil.Column{Name: "Overridden By"})
+	}
+	return printer.PrintObj(&cmdutil.Printable{Kind: "config", Object: origins, Columns: columns, Rows: rows}, out)
+}
+
+// formatConfigValue formats the value of a setting for the table, the
+// empty sections and lists are printed as json.
+func formatConfigValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// normalizeConfig converts the maps decoded by yaml.v2, whose keys are not
+// strings, so that the config can be printed as json.
+func normalizeConfig(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprintf("%v", key)] = normalizeConfig(value)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = normalizeConfig(value)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, 0, len(v))
+		for _, value := range v {
+			s = append(s, normalizeConfig(value))
+		}
+		return s
+	}
+	return v
+}
+
+// redactPasswords replaces the values of the password keys in place, the
+// secret references are kept since they are not secrets.
+func redactPasswords(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if key == "password" {
+				if s, ok := value.(string); ok && s != "" && !config.IsSecretRef(s) {
+					v[key] = redacted
+				}
+				continue
+			}
+			redactPasswords(value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			redactPasswords(value)
+		}
+	}
+}
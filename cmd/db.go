@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"io"
+
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdDB(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db SUBCOMMAND",
+		Short: i18n.T("Dump and restore the cmdctl tables"),
+		Long:  "Dump and restore the cmdctl tables in a portable format, which does not depend on the database driver",
+		Run: func(cmd *cobra.Command, args []string) {
+			// run sub command
+			defaultRunFunc := cmdutil.DefaultSubCommandRun(out)
+			defaultRunFunc(cmd, args)
+			return
+		},
+	}
+
+	// sub command
+	cmd.AddCommand(NewCmdDBDump(f, out, cmdErr))
+	cmd.AddCommand(NewCmdDBRestore(f, out, cmdErr))
+
+	return cmd
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dbDumpExample = templates.Examples(i18n.T(`
+	# Dump the cmdctl tables to a file
+	cmdctl db dump -o backup.json
+
+	# Dump the cmdctl tables to stdout
+	cmdctl db dump`))
+)
+
+func NewCmdDBDump(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "dump [-o FILENAME]",
+		Short:   i18n.T("Dump the cmdctl tables as json"),
+		Long:    "Dump all the rows of the cmdctl tables as json, including the soft deleted users. The dump records the schema version and the cmdctl version which wrote it",
+		Example: dbDumpExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunDBDump(f, out, cmdErr, cmd, args))
+			return
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "The file to write the dump to, the dump is written to stdout if empty.")
+	return cmdutil.SetPermission(cmd, PermissionDBDump)
+}
+
+func RunDBDump(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	output := cmdutil.GetFlagString(cmd, "output")
+
+	db, err := model.GetSelfDB()
+	if err != nil {
+		return err
+	}
+	dump, err := model.DumpDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if output == "" {
+		_, err := out.Write(data)
+		return err
+	}
+
+	// the dump holds the password hashes
+	if err := ioutil.WriteFile(output, data, 0600); err != nil {
+		return err
+	}
+
+	for _, t := range dump.Tables {
+		fmt.Fprintf(cmdErr, "%s: %d row(s) dumped\n", t.Name, len(t.Rows))
+	}
+	fmt.Fprintf(out, "schema version %d dumped to %s\n", dump.Header.SchemaVersion, output)
+	return nil
+}
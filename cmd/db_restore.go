@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dbRestoreExample = templates.Examples(i18n.T(`
+	# Restore the cmdctl tables from a dump, the tables must be empty
+	cmdctl db restore -f backup.json
+
+	# Replace the existing rows with the dump
+	cmdctl db restore -f backup.json --force`))
+)
+
+func NewCmdDBRestore(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "restore -f FILENAME",
+		Short:   i18n.T("Restore the cmdctl tables from a dump"),
+		Long:    "Restore the cmdctl tables from a dump in one transaction, the schema version of the dump must match the database",
+		Example: dbRestoreExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunDBRestore(f, out, cmdErr, cmd, args))
+			return
+		},
+	}
+
+	cmd.Flags().StringP("filename", "f", "", "The dump file written by `cmdctl db dump`, - reads from stdin.")
+	cmd.Flags().Bool("force", false, "Delete the existing rows before restoring.")
+	return cmdutil.SetPermission(cmd, PermissionDBRestore)
+}
+
+func RunDBRestore(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	filename := cmdutil.GetFlagString(cmd, "filename")
+	if filename == "" {
+		return cmdutil.UsageErrorf(cmd, "--filename must be specified")
+	}
+
+	data, err := readFileOrStdin(filename)
+	if err != nil {
+		return err
+	}
+
+	dump := &model.Dump{}
+	if err := json.Unmarshal(data, dump); err != nil {
+		return fmt.Errorf("parse %s failed: %v", filename, err)
+	}
+	fmt.Fprintf(cmdErr, "dump of schema version %d written by cmdctl %s at %s\n",
+		dump.Header.SchemaVersion, dump.Header.Version, dump.Header.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	db, err := model.GetSelfDB()
+	if err != nil {
+		return err
+	}
+	counts, err := model.RestoreDatabase(db, dump, cmdutil.GetFlagBool(cmd, "force"))
+	if err != nil {
+		return err
+	}
+
+	for _, t := range dump.Tables {
+		fmt.Fprintf(out, "%s: %d row(s) restored\n", t.Name, counts[t.Name])
+	}
+	return nil
+}
+
+// readFileOrStdin reads the file, or stdin if the filename is -.
+func readFileOrStdin(filename string) ([]byte, error) {
+	if filename == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(filename)
+}
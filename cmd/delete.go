@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+type DeleteOptions struct {
+	all bool
+	yes bool
+}
+
+var (
+	deleteExample = templates.Examples(i18n.T(`
+		# Delete the user lkong
+		cmdctl delete user lkong
+
+		# Delete several users
+		cmdctl delete user lkong colin
+
+		# Delete all users, you will be asked for confirmation
+		cmdctl delete user --all`))
+)
+
+func NewCmdDelete(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "delete user (NAME... | --all)",
+		Short:   i18n.T("Delete users by names"),
+		Long:    "Delete users by names, a non-zero exit code is returned if any user is not found",
+		Example: deleteExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options := new(DeleteOptions)
+			cmdutil.CheckErr(options.Complete(cmd))
+			cmdutil.CheckErr(options.Validate(cmd, args))
+			cmdutil.CheckErr(options.RunDelete(f, in, out, cmdErr, args))
+			return
+		},
+		Aliases: []string{"del"},
+	}
+
+	cmd.Flags().Bool("all", false, "Delete all users.")
+	cmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation when --all is specified.")
+	return cmdutil.SetPermission(cmd, PermissionUserDelete)
+}
+
+func (o *DeleteOptions) RunDelete(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer, args []string) error {
+	if o.all && !o.yes {
+		if !confirm(in, out, "Are you sure to delete all users? [y/N]: ") {
+			fmt.Fprintln(out, "aborted")
+			return nil
+		}
+	}
+
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if o.all {
+		count, err := store.DeleteAll()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%d user(s) deleted\n", count)
+		return nil
+	}
+
+	var notFound bool
+	for _, name := range args[1:] {
+		err := store.Delete(name)
+		if model.IsNotFound(err) {
+			fmt.Fprintf(cmdErr, "Error: user %q not found\n", name)
+			notFound = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "user %q deleted\n", name)
+	}
+
+	if notFound {
+		return cmdutil.ErrExit
+	}
+	return nil
+}
+
+func (o *DeleteOptions) Complete(cmd *cobra.Command) error {
+	o.all = cmdutil.GetFlagBool(cmd, "all")
+	o.yes = cmdutil.GetFlagBool(cmd, "yes")
+	return nil
+}
+
+func (o *DeleteOptions) Validate(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmdutil.UsageErrorf(cmd, "Required resource not specified.")
+	}
+	if err := validateUserResource(cmd, args[0]); err != nil {
+		return err
+	}
+
+	if o.all && len(args) > 1 {
+		return cmdutil.UsageErrorf(cmd, "user names can not be specified together with --all")
+	}
+	if !o.all && len(args) < 2 {
+		return cmdutil.UsageErrorf(cmd, "at least one user name or --all must be specified")
+	}
+
+	return nil
+}
+
+// confirm prints the prompt and reads the answer from in, only 'y' or 'yes' is
+// treated as a confirmation.
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprint(out, prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+
+	return false
+}
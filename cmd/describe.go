@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	describeExample = templates.Examples(i18n.T(`
+		# Describe the user lkong
+		cmdctl describe user lkong`))
+)
+
+func NewCmdDescribe(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "describe user NAME",
+		Short:   i18n.T("Show details of a specific user"),
+		Long:    "Show details of a specific user, including the creation and update time",
+		Example: describeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunDescribe(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{"desc"},
+	}
+
+	return cmd
+}
+
+func RunDescribe(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	if err := validateUserResource(cmd, args[0]); err != nil {
+		return err
+	}
+
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	user, err := store.Get(args[1])
+	if model.IsNotFound(err) {
+		return fmt.Errorf("user %q not found", args[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", user.Username)
+	fmt.Fprintf(w, "Email:\t%s\n", user.Email)
+	fmt.Fprintf(w, "Attributes:\t%s\n", formatAttributes(user.Attributes))
+	fmt.Fprintf(w, "Created:\t%s (%s ago)\n", user.CreatedAt.Format(time.RFC1123Z), translateTimestamp(user.CreatedAt))
+	fmt.Fprintf(w, "Updated:\t%s (%s ago)\n", user.UpdatedAt.Format(time.RFC1123Z), translateTimestamp(user.UpdatedAt))
+	return w.Flush()
+}
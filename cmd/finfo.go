@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
 	"cmdctl/cmd/templates"
 	cmdutil "cmdctl/cmd/util"
@@ -13,7 +16,10 @@ import (
 var (
 	finfoExample = templates.Examples(`
 		# Get http server basic information(show how to send http request)
-		cmdctl finfo`)
+		cmdctl finfo
+
+		# Print the status fields as a table
+		cmdctl finfo -o table`)
 )
 
 func NewCmdFinfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
@@ -29,13 +35,25 @@ func NewCmdFinfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Comm
 		Aliases: []string{},
 	}
 
+	cmdutil.AddOutputFlag(cmd, "Output format. One of: "+strings.Join(cmdutil.OutputFormats, "|")+", the response body is printed as is if empty.")
 	return cmd
 }
 
 func RunFinfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	output := cmdutil.OutputFormat(cmd)
+	printer, err := cmdutil.PrinterFor(output)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	auth, err := f.Auth()
+	if err != nil {
+		return err
+	}
+
 	request := f.Gorequest()
 	resp, body, errs := request.Get("http://"+f.FileServer().Server+"/-/status").
-		Set("Authorization", "Basic "+f.Auth()).
+		Set("Authorization", "Basic "+auth).
 		Send(``).
 		End()
 
@@ -43,6 +61,41 @@ func RunFinfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Com
 		return err
 	}
 
-	fmt.Printf("%s\n", body)
-	return nil
+	if output == "" {
+		fmt.Fprintf(out, "%s\n", body)
+		return nil
+	}
+	return printer.PrintObj(statusPrintable(body), out)
+}
+
+// statusPrintable returns the status in the form of the printers, a field
+// per row if the body is a json object.
+func statusPrintable(body string) *cmdutil.Printable {
+	obj := &cmdutil.Printable{
+		Kind:    "status",
+		Columns: []cmdutil.Column{{Name: "Field"}, {Name: "Value"}},
+	}
+
+	var status map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &status); err != nil {
+		obj.Object = body
+		obj.Rows = []cmdutil.Row{{Name: "body", Cells: []string{"body", body}}}
+		return obj
+	}
+
+	obj.Object = status
+	fields := make([]string, 0, len(status))
+	for field := range status {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		value, ok := status[field].(string)
+		if !ok {
+			data, _ := json.Marshal(status[field])
+			value = string(data)
+		}
+		obj.Rows = append(obj.Rows, cmdutil.Row{Name: field, Cells: []string{field, value}})
+	}
+	return obj
 }
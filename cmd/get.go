@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	getExample = templates.Examples(i18n.T(`
+		# Get all users
+		cmdctl get users
+
+		# Get the user lkong
+		cmdctl get user lkong
+
+		# Get the users lkong and colin as yaml
+		cmdctl get user lkong colin -o yaml
+
+		# Print the names of all users
+		cmdctl get users -o name`))
+)
+
+func NewCmdGet(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "get user [NAME...]",
+		Short:   i18n.T("Display one or many users"),
+		Long:    "Display one or many users",
+		Example: getExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunGet(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmdutil.AddOutputFlag(cmd, "")
+	cmdutil.AddTableFlags(cmd, true)
+	return cmd
+}
+
+func RunGet(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmdutil.UsageErrorf(cmd, "Required resource not specified.")
+	}
+	if err := validateUserResource(cmd, args[0]); err != nil {
+		return err
+	}
+	printer, err := cmdutil.PrinterForCommand(cmd)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	users := make([]*model.UserModel, 0)
+	if len(args) == 1 {
+		list, _, err := store.List(&model.ListUserOptions{})
+		if err != nil {
+			return err
+		}
+		users = list
+	}
+
+	var notFound bool
+	for _, name := range args[1:] {
+		user, err := store.Get(name)
+		if model.IsNotFound(err) {
+			fmt.Fprintf(cmdErr, "Error: user %q not found\n", name)
+			notFound = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		users = append(users, user)
+	}
+
+	// the users are printed as list does, nothing is printed if none of
+	// the named users is found
+	if len(users) > 0 || len(args) == 1 {
+		if err := printer.PrintObj(new(ListOptions).printable(users), out); err != nil {
+			return err
+		}
+	}
+
+	if notFound {
+		return cmdutil.ErrExit
+	}
+	return nil
+}
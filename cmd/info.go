@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"io"
-	"reflect"
 	"strconv"
 
 	"cmdctl/cmd/templates"
@@ -16,12 +15,12 @@ import (
 )
 
 type Info struct {
-	HostName  string
-	IPAddress string
-	OSRelease string
-	CPUCore   uint64
-	MemTotal  string
-	MemFree   string
+	HostName  string `json:"hostName"`
+	IPAddress string `json:"ipAddress"`
+	OSRelease string `json:"osRelease"`
+	CPUCore   uint64 `json:"cpuCore"`
+	MemTotal  string `json:"memTotal"`
+	MemFree   string `json:"memFree"`
 }
 
 var (
@@ -33,7 +32,16 @@ var (
 		cmdctl info -p newpass
 
 		# Print details
-		cmdctl info -d`))
+		cmdctl info -d
+
+		# Print the host name only
+		cmdctl info -o jsonpath='{.hostName}'
+
+		# Print the host information as yaml
+		cmdctl info -o yaml
+
+		# Watch the free memory of the host
+		cmdctl info -w`))
 )
 
 func NewCmdInfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
@@ -49,6 +57,8 @@ func NewCmdInfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Comma
 
 	cmd.Flags().StringP("passwd", "p", "", "Specify the server password.")
 	cmd.Flags().BoolP("detail", "d", false, "Print details.")
+	cmdutil.AddOutputFlag(cmd, "")
+	cmdutil.AddWatchFlags(cmd)
 
 	return cmd
 }
@@ -56,14 +66,41 @@ func NewCmdInfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Comma
 func RunInfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
 	passwd := cmdutil.GetFlagString(cmd, "passwd")
 	detail := cmdutil.GetFlagBool(cmd, "detail")
+	printer, err := cmdutil.PrinterForCommand(cmd)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
 	if detail {
-		fmt.Printf("%12s %v\n", "OptionValue"+":", fmt.Sprintf("%v:%s", detail, passwd))
+		fmt.Fprintf(cmdErr, "%12s %v\n", "OptionValue"+":", fmt.Sprintf("%v:%s", detail, passwd))
 	}
 
+	if cmdutil.GetFlagBool(cmd, "watch") {
+		watcher, err := cmdutil.NewWatcher(cmd, out, cmdutil.OutputFormat(cmd), cmdutil.TableOptionsForCommand(cmd))
+		if err != nil {
+			return cmdutil.UsageErrorf(cmd, "%s", err)
+		}
+		return watcher.Run(func() (*cmdutil.Printable, error) {
+			info, err := getInfo()
+			if err != nil {
+				return nil, err
+			}
+			return info.printable(), nil
+		})
+	}
+
+	info, err := getInfo()
+	if err != nil {
+		return err
+	}
+	return printer.PrintObj(info.printable(), out)
+}
+
+// getInfo collects the host information.
+func getInfo() (*Info, error) {
 	var info Info
 	host_info, err := host_stat.GetHostInfo()
 	if err != nil {
-		return fmt.Errorf("get host info failed!error:%v", err)
+		return nil, fmt.Errorf("get host info failed!error:%v", err)
 	}
 
 	info.HostName = host_info.HostName
@@ -71,28 +108,45 @@ func RunInfo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Comm
 
 	mem_stat, err := host_stat.GetMemStat()
 	if err != nil {
-		return fmt.Errorf("get mem stat failed!error:%v", err)
+		return nil, fmt.Errorf("get mem stat failed!error:%v", err)
 	}
 	info.MemTotal = strconv.FormatUint(mem_stat.MemTotal, 10) + "M"
 	info.MemFree = strconv.FormatUint(mem_stat.MemFree, 10) + "M"
 
 	cpu_stat, err := host_stat.GetCPUInfo()
 	if err != nil {
-		return fmt.Errorf("get cpu stat failed!error:%v", err)
+		return nil, fmt.Errorf("get cpu stat failed!error:%v", err)
 	}
 	info.CPUCore = cpu_stat.CoreCount
 
 	info.IPAddress = util.GetLocalAddress()
 
-	s := reflect.ValueOf(&info).Elem()
-	typeOfInfo := s.Type()
-	for i := 0; i < s.NumField(); i++ {
-		f := s.Field(i)
-		v := fmt.Sprintf("%v", f.Interface())
-		if v != "" {
-			fmt.Printf("%12s %v\n", typeOfInfo.Field(i).Name+":", f.Interface())
-		}
-	}
+	return &info, nil
+}
 
-	return nil
+// printable returns the host information in the form of the printers.
+func (info *Info) printable() *cmdutil.Printable {
+	return &cmdutil.Printable{
+		Kind:   "host",
+		Object: info,
+		Columns: []cmdutil.Column{
+			{Name: "HostName"},
+			{Name: "IPAddress"},
+			{Name: "CPUCore"},
+			{Name: "MemTotal"},
+			{Name: "MemFree"},
+			{Name: "OSRelease", Wide: true},
+		},
+		Rows: []cmdutil.Row{{
+			Name: info.HostName,
+			Cells: []string{
+				info.HostName,
+				info.IPAddress,
+				strconv.FormatUint(info.CPUCore, 10),
+				info.MemTotal,
+				info.MemFree,
+				info.OSRelease,
+			},
+		}},
+	}
 }
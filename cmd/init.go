@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"database/sql"
 	"fmt"
 
 	"cmdctl/cmd/templates"
@@ -9,7 +8,6 @@ import (
 	"cmdctl/model"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var (
@@ -39,12 +37,21 @@ func NewCmdInit() *cobra.Command {
 
 	cmd.Flags().BoolP("force", "f", false, "Drop table if exists")
 
-	return cmd
+	return cmdutil.SetPermission(cmd, PermissionDBInit)
 }
 
 func RunInit(cmd *cobra.Command, args []string) error {
 	force := cmdutil.GetFlagBool(cmd, "force")
 
+	c, err := model.LoadDataSourceConfig(model.SelfDataSource)
+	if err != nil {
+		return err
+	}
+	if c.Driver == model.MemoryDriver {
+		fmt.Println("the memory driver needs no database, nothing to init")
+		return nil
+	}
+
 	if err := Createdb(force); err != nil {
 		return err
 	}
@@ -59,46 +66,32 @@ func RunInit(cmd *cobra.Command, args []string) error {
 
 //创建数据库
 func Createdb(force bool) error {
-	db_type := "mysql" // current only support mysql
-	db_addr := viper.GetString("db.addr")
-	db_user := viper.GetString("db.username")
-	db_pass := viper.GetString("db.password")
-	db_name := viper.GetString("db.name")
-
-	var dns string
-	var sqlstring, sql1string string
-
-	dns = fmt.Sprintf("%s:%s@tcp(%s)/?charset=utf8", db_user, db_pass, db_addr)
-	sql1string = fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", db_name)
-	sqlstring = fmt.Sprintf("CREATE DATABASE if not exists `%s` CHARSET utf8 COLLATE utf8_general_ci", db_name)
-
-	db, err := sql.Open(db_type, dns)
+	c, err := model.LoadDataSourceConfig(model.SelfDataSource)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
-
-	if force {
-		fmt.Println(sql1string)
-		if _, err := db.Exec(sql1string); err != nil {
-			return err
-		}
+	driver, err := model.GetDriver(c.Driver)
+	if err != nil {
+		return err
 	}
 
-	if _, err := db.Exec(sqlstring); err != nil {
+	if err := driver.CreateDatabase(c.DBConfig, force); err != nil {
 		return err
 	}
 
-	fmt.Printf("database %s created\n", db_name)
+	if force {
+		fmt.Printf("database %s dropped\n", c.Name)
+	}
+	fmt.Printf("database %s created\n", c.Name)
 	return nil
 }
 
 func Createtb() error {
-	db := model.GetSelfDB()
-	defer db.Close()
-	if err := db.AutoMigrate(&model.UserModel{}).Error; err != nil {
+	db, err := model.GetSelfDB()
+	if err != nil {
 		return err
 	}
-
-	return nil
+	return model.MigrateUp(db, func(m *model.Migration, up bool) {
+		fmt.Printf("migration %d_%s applied\n", m.Version, m.Name)
+	})
 }
@@ -1,22 +1,85 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"cmdctl/cmd/templates"
 	cmdutil "cmdctl/cmd/util"
 	"cmdctl/model"
 	"cmdctl/pkg/i18n"
+	"cmdctl/pkg/selector"
 
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
+type ListOptions struct {
+	model.ListUserOptions
+
+	page        int
+	stream      bool
+	chunkSize   int
+	attrColumns []string
+	output      string
+	watch       bool
+	// tableOptions sort the rows by the columns other than username and
+	// createdAt, which are sorted by the query.
+	tableOptions cmdutil.TableOptions
+}
+
+// userOutput is the form of a user printed by -o json and -o yaml, the
+// password hash is left out.
+type userOutput struct {
+	Username   string           `json:"username"`
+	Email      string           `json:"email,omitempty"`
+	Attributes model.Attributes `json:"attributes,omitempty"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	UpdatedAt  time.Time        `json:"updatedAt"`
+	DeletedAt  *time.Time       `json:"deletedAt,omitempty"`
+}
+
 var (
 	listExample = templates.Examples(i18n.T(`
 	# List existing users
-	cmdctl list`))
+	cmdctl list
+
+	# List users whose name contains 'kong' and whose email is under qq.com
+	cmdctl list --filter kong --email-domain qq.com
+
+	# List the second page of users sorted by username, 20 users per page
+	cmdctl list --sort-by username --limit 20 --page 2
+
+	# Walk through a very large user table 1000 rows at a time
+	cmdctl list --stream --chunk-size 1000
+
+	# List the deleted users which can be restored by 'cmdctl user restore'
+	cmdctl list --deleted
+
+	# List the users of the infra team which are not on call, along with their team and tier
+	cmdctl list -l 'team=infra,tier!=oncall' -L team,tier
+
+	# List the users of several teams
+	cmdctl list -l 'team in (infra,sre)'
+
+	# List the users as json
+	cmdctl list -o json
+
+	# Print the usernames and emails as columns
+	cmdctl list -o custom-columns=NAME:.username,EMAIL:.email
+
+	# Print the usernames with a jsonpath or a go template
+	cmdctl list -o jsonpath='{range .[*]}{.username}{"\n"}{end}'
+	cmdctl list -o go-template='{{range .}}{{.username}} {{end}}'
+
+	# List the usernames and teams only, sorted by team, without the headers
+	cmdctl list -L team --columns username,team --sort-by team --no-headers
+
+	# Watch the users of the infra team, polling every 5 seconds
+	cmdctl list -l team=infra -w --interval 5s`))
 )
 
 func NewCmdList(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
@@ -26,31 +89,241 @@ func NewCmdList(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Comma
 		Long:    "List existing users",
 		Example: listExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(RunList(f, out, cmdErr, cmd, args))
+			options := new(ListOptions)
+			cmdutil.CheckErr(options.Complete(cmd))
+			if err := options.Validate(); err != nil {
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "%s", err))
+			}
+			cmdutil.CheckErr(options.RunList(f, out, cmdErr, cmd, args))
 			return
 		},
 		Aliases: []string{"li"},
 	}
 
+	cmd.Flags().String("filter", "", "Only list users whose name contains the given string.")
+	cmd.Flags().String("email-domain", "", "Only list users whose email is under the given domain.")
+	cmd.Flags().Int("limit", 0, "The max number of users to list, 0 means no limit.")
+	cmd.Flags().Int("offset", 0, "The number of users to skip.")
+	cmd.Flags().Int("page", 0, "The page to list, starting at 1, requires --limit.")
+	cmd.Flags().String("sort-by", "", "Sort users by 'username', 'createdAt' or any other column of the output, the newest users are listed first by default. The other columns can not be combined with --limit, --offset or --page.")
+	cmd.Flags().Bool("reverse", false, "Reverse the sort order.")
+	cmd.Flags().Bool("stream", false, "Walk through the users in chunks instead of loading them all into memory.")
+	cmd.Flags().Int("chunk-size", 500, "The number of users fetched per query in --stream mode.")
+	cmd.Flags().Bool("deleted", false, "List the deleted users only.")
+	cmd.Flags().Bool("include-deleted", false, "List the deleted users along with the others.")
+	cmd.Flags().StringP("selector", "l", "", "Selector on the attributes to filter on, supports '=', '==', '!=', 'in' and 'notin' (e.g. -l 'team=infra,tier in (oncall,backup)').")
+	cmd.Flags().StringSliceP("attribute-columns", "L", []string{}, "The attributes to show as columns.")
+	cmdutil.AddOutputFlag(cmd, "")
+	cmdutil.AddTableFlags(cmd, false)
+	cmdutil.AddWatchFlags(cmd)
+
 	return cmd
 }
 
-func RunList(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
-	model.DB.Init()
-	defer model.DB.Close()
+func (o *ListOptions) RunList(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if o.stream {
+		return o.runStream(store, out)
+	}
 
-	users, _, err := model.ListUser("", 0, 0)
+	if o.page > 0 {
+		o.Offset = (o.page - 1) * o.Limit
+	}
+
+	printer, err := cmdutil.NewPrinter(o.output, o.tableOptions)
 	if err != nil {
 		return err
 	}
 
-	table := tablewriter.NewWriter(out)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetColWidth(TABLE_WIDTH)
-	table.SetHeader([]string{"Username", "Password", "Email"})
+	poll := func() (*cmdutil.Printable, error) {
+		users, _, err := store.List(&o.ListUserOptions)
+		if err != nil {
+			return nil, err
+		}
+		return o.printable(users), nil
+	}
+
+	if o.watch {
+		watcher, err := cmdutil.NewWatcher(cmd, out, o.output, o.tableOptions)
+		if err != nil {
+			return cmdutil.UsageErrorf(cmd, "%s", err)
+		}
+		return watcher.Run(poll)
+	}
+
+	obj, err := poll()
+	if err != nil {
+		return err
+	}
+	return printer.PrintObj(obj, out)
+}
+
+// printable returns the users in the form of the printers.
+func (o *ListOptions) printable(users []*model.UserModel) *cmdutil.Printable {
+	showDeleted := o.IncludeDeleted || o.OnlyDeleted
+	columns := []cmdutil.Column{
+		{Name: "Username", Color: func(v string) string { return color.RedString(v) }},
+		{Name: "Email"},
+	}
+	if showDeleted {
+		columns = append(columns, cmdutil.Column{Name: "Deleted"})
+	}
+	for _, attr := range o.attrColumns {
+		columns = append(columns, cmdutil.Column{Name: attr})
+	}
+	columns = append(columns,
+		cmdutil.Column{Name: "Attributes", Wide: true},
+		cmdutil.Column{Name: "Age", Wide: true},
+	)
+
+	objects := make([]*userOutput, 0, len(users))
+	rows := make([]cmdutil.Row, 0, len(users))
 	for _, user := range users {
-		table.Append([]string{color.RedString(user.Username), user.Password, user.Email})
+		objects = append(objects, &userOutput{
+			Username:   user.Username,
+			Email:      user.Email,
+			Attributes: user.Attributes,
+			CreatedAt:  user.CreatedAt,
+			UpdatedAt:  user.UpdatedAt,
+			DeletedAt:  user.DeletedAt,
+		})
+
+		cells := []string{user.Username, user.Email}
+		if showDeleted {
+			cells = append(cells, deletedAge(user))
+		}
+		cells = append(cells, o.attributeValues(user)...)
+		cells = append(cells, formatAttributes(user.Attributes), translateTimestamp(user.CreatedAt))
+		rows = append(rows, cmdutil.Row{Name: user.Username, Cells: cells})
+	}
+
+	return &cmdutil.Printable{Kind: "user", Object: objects, Columns: columns, Rows: rows}
+}
+
+// runStream prints the users chunk by chunk, a table can not be used here
+// because it needs all the rows before rendering.
+func (o *ListOptions) runStream(store model.UserStore, out io.Writer) error {
+	showDeleted := o.IncludeDeleted || o.OnlyDeleted
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	if !o.tableOptions.NoHeaders {
+		header := []string{"USERNAME", "EMAIL"}
+		if showDeleted {
+			header = append(header, "DELETED")
+		}
+		for _, column := range o.attrColumns {
+			header = append(header, strings.ToUpper(column))
+		}
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+	}
+	return store.Walk(&o.ListUserOptions, o.chunkSize, func(users []*model.UserModel) error {
+		for _, user := range users {
+			row := []string{user.Username, user.Email}
+			if showDeleted {
+				row = append(row, deletedAge(user))
+			}
+			row = append(row, o.attributeValues(user)...)
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	})
+}
+
+// attributeValues returns the values of the attribute columns of the user.
+func (o *ListOptions) attributeValues(user *model.UserModel) []string {
+	values := make([]string, 0, len(o.attrColumns))
+	for _, column := range o.attrColumns {
+		values = append(values, user.Attributes[column])
+	}
+	return values
+}
+
+// deletedAge returns how long ago the user was deleted, or empty if not deleted.
+func deletedAge(user *model.UserModel) string {
+	if user.DeletedAt == nil {
+		return ""
+	}
+	return translateTimestamp(*user.DeletedAt) + " ago"
+}
+
+func (o *ListOptions) Complete(cmd *cobra.Command) error {
+	o.Filter = cmdutil.GetFlagString(cmd, "filter")
+	o.EmailDomain = cmdutil.GetFlagString(cmd, "email-domain")
+	o.Limit = cmdutil.GetFlagInt(cmd, "limit")
+	o.Offset = cmdutil.GetFlagInt(cmd, "offset")
+	o.SortBy = cmdutil.GetFlagString(cmd, "sort-by")
+	o.Reverse = cmdutil.GetFlagBool(cmd, "reverse")
+	o.page = cmdutil.GetFlagInt(cmd, "page")
+	o.stream = cmdutil.GetFlagBool(cmd, "stream")
+	o.chunkSize = cmdutil.GetFlagInt(cmd, "chunk-size")
+	o.OnlyDeleted = cmdutil.GetFlagBool(cmd, "deleted")
+	o.IncludeDeleted = cmdutil.GetFlagBool(cmd, "include-deleted")
+	o.attrColumns = cmdutil.GetFlagStringSlice(cmd, "attribute-columns")
+	o.output = cmdutil.OutputFormat(cmd)
+	o.watch = cmdutil.GetFlagBool(cmd, "watch")
+	o.tableOptions = cmdutil.TableOptionsForCommand(cmd)
+	o.tableOptions.SortBy = ""
+	if o.SortBy != "" && o.SortBy != "username" && o.SortBy != "createdAt" {
+		o.tableOptions.SortBy, o.tableOptions.Reverse = o.SortBy, o.Reverse
+		o.SortBy = ""
 	}
-	table.Render()
+
+	var err error
+	if o.Selector, err = selector.Parse(cmdutil.GetFlagString(cmd, "selector")); err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+	return nil
+}
+
+func (o *ListOptions) Validate() error {
+	if o.Limit < 0 || o.Offset < 0 || o.page < 0 {
+		return fmt.Errorf("--limit, --offset and --page can not be negative")
+	}
+	if o.page > 0 && o.Limit == 0 {
+		return fmt.Errorf("--page requires --limit")
+	}
+	if o.page > 0 && o.Offset > 0 {
+		return fmt.Errorf("--page and --offset can not be used together")
+	}
+	if o.OnlyDeleted && o.IncludeDeleted {
+		return fmt.Errorf("--deleted and --include-deleted can not be used together")
+	}
+	// the columns other than username and createdAt are sorted after the page is read
+	if o.tableOptions.SortBy != "" && (o.Limit > 0 || o.Offset > 0) {
+		return fmt.Errorf("--sort-by %s can not be used with --limit, --offset or --page, only username and createdAt are sorted before paging", o.tableOptions.SortBy)
+	}
+	if _, err := cmdutil.NewPrinter(o.output, o.tableOptions); err != nil {
+		return err
+	}
+	// the columns are known before the users are listed
+	if _, err := o.tableOptions.Apply(o.printable(nil)); err != nil {
+		return err
+	}
+
+	if o.stream {
+		if o.watch {
+			return fmt.Errorf("--stream can not be used with --watch")
+		}
+		if o.output != "" {
+			return fmt.Errorf("--stream can not be used with --output")
+		}
+		if o.Limit > 0 || o.Offset > 0 {
+			return fmt.Errorf("--stream can not be used with --limit, --offset or --page")
+		}
+		if o.SortBy == "username" || o.tableOptions.SortBy != "" {
+			return fmt.Errorf("--stream can only be used with --sort-by=createdAt")
+		}
+		if len(o.tableOptions.Columns) > 0 {
+			return fmt.Errorf("--stream can not be used with --columns")
+		}
+		if o.chunkSize <= 0 {
+			return fmt.Errorf("--chunk-size must be greater than 0")
+		}
+	}
+
 	return nil
 }
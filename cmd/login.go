@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginExample = templates.Examples(i18n.T(`
+		# Log in as lkong, the following commands are authorized as lkong
+		cmdctl login lkong
+
+		# Log in as lkong with the password read from stdin
+		cat passwd.txt | cmdctl login lkong --password-stdin`))
+
+	logoutExample = templates.Examples(i18n.T(`
+		# Log out the current user
+		cmdctl logout`))
+)
+
+func NewCmdLogin(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "login USERNAME [PASSWORD]",
+		Short:   i18n.T("Log in as a user"),
+		Long:    "Log in as a user, the user is used to check the permissions of mutating commands. The password is prompted for if omitted",
+		Example: loginExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunLogin(f, in, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	addPasswordStdinFlag(cmd)
+	return cmdutil.SetSecretArgs(cmd, 1)
+}
+
+func RunLogin(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	if len(args) == 2 && cmdutil.GetFlagBool(cmd, "password-stdin") {
+		return cmdutil.UsageErrorf(cmd, "--password-stdin can not be used with the PASSWORD argument")
+	}
+
+	var password string
+	if len(args) == 2 {
+		password = args[1]
+	} else {
+		var err error
+		if password, err = readPassword(cmd, in, cmdErr, false); err != nil {
+			return err
+		}
+	}
+
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := model.CheckPassword(store, args[0], password); err != nil {
+		if model.IsNotFound(err) {
+			return model.ErrPasswordMismatch
+		}
+		return err
+	}
+
+	if err := saveLogin(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "logged in as %q\n", args[0])
+	return nil
+}
+
+func NewCmdLogout(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "logout",
+		Short:   i18n.T("Log out the current user"),
+		Long:    "Log out the current user",
+		Example: logoutExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := os.Remove(loginFile()); err != nil && !os.IsNotExist(err) {
+				cmdutil.CheckErr(err)
+			}
+			fmt.Fprintln(out, "logged out")
+		},
+		Aliases: []string{},
+	}
+
+	return cmd
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdMigrate(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate SUBCOMMAND",
+		Short: i18n.T("Apply or roll back database schema migrations"),
+		Long:  "Apply or roll back database schema migrations, the applied versions are recorded in tb_cmdctl_schema_migrations",
+		Run: func(cmd *cobra.Command, args []string) {
+			// run sub command
+			defaultRunFunc := cmdutil.DefaultSubCommandRun(out)
+			defaultRunFunc(cmd, args)
+			return
+		},
+		Aliases: []string{"mg"},
+	}
+
+	// sub command
+	cmd.AddCommand(NewCmdMigrateUp(f, out, cmdErr))
+	cmd.AddCommand(NewCmdMigrateDown(f, out, cmdErr))
+	cmd.AddCommand(NewCmdMigrateStatus(f, out, cmdErr))
+	cmd.AddCommand(NewCmdMigrateTo(f, out, cmdErr))
+
+	return cmd
+}
+
+// printMigration returns a callback which reports every applied or rolled back migration.
+func printMigration(out io.Writer) func(m *model.Migration, up bool) {
+	return func(m *model.Migration, up bool) {
+		action := "applied"
+		if !up {
+			action = "rolled back"
+		}
+		fmt.Fprintf(out, "migration %d_%s %s\n", m.Version, m.Name, action)
+	}
+}
+
+// printVersion prints the current schema version.
+func printVersion(out io.Writer, version uint) {
+	fmt.Fprintf(out, "current version: %d, latest version: %d\n", version, model.LatestVersion())
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateDownExample = templates.Examples(i18n.T(`
+	# Roll back the latest migration
+	cmdctl migrate down
+
+	# Roll back the latest 2 migrations
+	cmdctl migrate down -n 2`))
+)
+
+func NewCmdMigrateDown(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "down",
+		Short:   i18n.T("Roll back the latest migrations"),
+		Long:    "Roll back the latest migrations",
+		Example: migrateDownExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunMigrateDown(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmd.Flags().IntP("steps", "n", 1, "The number of migrations to roll back.")
+	return cmdutil.SetPermission(cmd, PermissionDBMigrate)
+}
+
+func RunMigrateDown(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	steps := cmdutil.GetFlagInt(cmd, "steps")
+	if steps < 1 {
+		return cmdutil.UsageErrorf(cmd, "--steps must be greater than 0")
+	}
+
+	db, err := model.GetSelfDB()
+	if err != nil {
+		return err
+	}
+
+	if err := model.MigrateDown(db, steps, printMigration(out)); err != nil {
+		return err
+	}
+
+	version, err := model.CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	printVersion(out, version)
+	return nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// migrationOutput is the form of a migration printed by -o json and -o yaml.
+type migrationOutput struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+var (
+	migrateStatusExample = templates.Examples(i18n.T(`
+	# Show the applied and pending migrations
+	cmdctl migrate status
+
+	# Print the version and the state of every migration
+	cmdctl migrate status -o jsonpath='{range .[*]}{.version} {.applied}{"\n"}{end}'`))
+)
+
+func NewCmdMigrateStatus(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "status",
+		Short:   i18n.T("Show the applied and pending migrations"),
+		Long:    "Show the applied and pending migrations",
+		Example: migrateStatusExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunMigrateStatus(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{"st"},
+	}
+
+	cmdutil.AddOutputFlag(cmd, "")
+	cmdutil.AddTableFlags(cmd, true)
+	return cmd
+}
+
+func RunMigrateStatus(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	format := cmdutil.OutputFormat(cmd)
+	printer, err := cmdutil.PrinterForCommand(cmd)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	db, err := model.GetSelfDB()
+	if err != nil {
+		return err
+	}
+
+	status, err := model.GetMigrationStatus(db)
+	if err != nil {
+		return err
+	}
+
+	version, err := model.CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	// the version line would break the machine-readable formats
+	if format == "" || format == "table" || format == "wide" {
+		printVersion(out, version)
+	}
+
+	objects := make([]*migrationOutput, 0, len(status))
+	rows := make([]cmdutil.Row, 0, len(status))
+	for _, s := range status {
+		object := &migrationOutput{Version: s.Version, Name: s.Name, Applied: s.Applied}
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			object.AppliedAt = &s.AppliedAt
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		objects = append(objects, object)
+		rows = append(rows, cmdutil.Row{
+			Name:  strconv.FormatUint(uint64(s.Version), 10) + "_" + s.Name,
+			Cells: []string{strconv.FormatUint(uint64(s.Version), 10), s.Name, state, appliedAt},
+		})
+	}
+
+	return printer.PrintObj(&cmdutil.Printable{
+		Kind:   "migration",
+		Object: objects,
+		Columns: []cmdutil.Column{
+			{Name: "Version"},
+			{Name: "Name"},
+			{Name: "Status", Color: func(v string) string {
+				if v == "applied" {
+					return color.GreenString(v)
+				}
+				return color.YellowString(v)
+			}},
+			{Name: "AppliedAt"},
+		},
+		Rows: rows,
+	}, out)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"io"
+	"strconv"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateToExample = templates.Examples(i18n.T(`
+	# Migrate the schema up or down to version 1
+	cmdctl migrate to 1
+
+	# Roll back all migrations
+	cmdctl migrate to 0`))
+)
+
+func NewCmdMigrateTo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "to VERSION",
+		Short:   i18n.T("Migrate the schema up or down to a specific version"),
+		Long:    "Migrate the schema up or down to a specific version",
+		Example: migrateToExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunMigrateTo(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionDBMigrate)
+}
+
+func RunMigrateTo(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	target, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s is not a valid version", args[0])
+	}
+
+	db, err := model.GetSelfDB()
+	if err != nil {
+		return err
+	}
+
+	if err := model.MigrateTo(db, uint(target), printMigration(out)); err != nil {
+		return err
+	}
+
+	version, err := model.CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	printVersion(out, version)
+	return nil
+}
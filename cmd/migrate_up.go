@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateUpExample = templates.Examples(i18n.T(`
+	# Apply all pending migrations
+	cmdctl migrate up`))
+)
+
+func NewCmdMigrateUp(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "up",
+		Short:   i18n.T("Apply all pending migrations"),
+		Long:    "Apply all pending migrations",
+		Example: migrateUpExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunMigrateUp(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionDBMigrate)
+}
+
+func RunMigrateUp(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	db, err := model.GetSelfDB()
+	if err != nil {
+		return err
+	}
+
+	if err := model.MigrateUp(db, printMigration(out)); err != nil {
+		return err
+	}
+
+	version, err := model.CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	printVersion(out, version)
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	cmdterm "cmdctl/cmd/term"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/interrupt"
+
+	"github.com/spf13/cobra"
+)
+
+// InterruptedExitCode is the exit code when a prompt is interrupted by Ctrl-C.
+const InterruptedExitCode = 130
+
+// addPasswordStdinFlag adds the --password-stdin flag read by readPassword.
+func addPasswordStdinFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("password-stdin", false, "Read the password from the first line of stdin.")
+}
+
+// readPassword reads the password from stdin if --password-stdin is set, or
+// prompts for it on the terminal without echo. A new password is asked twice.
+func readPassword(cmd *cobra.Command, in io.Reader, cmdErr io.Writer, newPassword bool) (string, error) {
+	if cmdutil.GetFlagBool(cmd, "password-stdin") {
+		password, err := cmdterm.ReadLine(in)
+		if err == io.EOF {
+			return "", fmt.Errorf("no password read from stdin")
+		}
+		return password, err
+	}
+
+	tty := cmdterm.TTY{
+		In:  in,
+		Out: cmdErr,
+		// the terminal is restored first, then the exit hooks run
+		Parent: interrupt.New(func(os.Signal) {
+			cmdutil.Exit(InterruptedExitCode)
+		}),
+	}
+
+	var (
+		password string
+		err      error
+	)
+	if newPassword {
+		password, err = tty.ReadNewSecret("Password: ", "Confirm password: ")
+	} else {
+		password, err = tty.ReadSecret("Password: ")
+	}
+	if err == cmdterm.ErrNotTerminal {
+		return "", fmt.Errorf("stdin is not a terminal, give the password by --password-stdin")
+	}
+	return password, err
+}
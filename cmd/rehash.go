@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rehashExample = templates.Examples(i18n.T(`
+		# Hash the passwords which are still stored in plain text
+		cmdctl rehash`))
+)
+
+func NewCmdRehash(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rehash",
+		Short:   i18n.T("Hash the plain text passwords of existing users"),
+		Long:    "Hash the plain text passwords of existing users, it is safe to run more than once",
+		Example: rehashExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunRehash(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionUserUpdate)
+}
+
+func RunRehash(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	count, err := store.Rehash()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%d password(s) rehashed\n", count)
+	return nil
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"io"
+
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdRole(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "role SUBCOMMAND",
+		Short: i18n.T("Manage roles and grant them to users"),
+		Long:  "Manage roles and grant them to users, the permissions are enforced when rbac.enabled is true",
+		Run: func(cmd *cobra.Command, args []string) {
+			// run sub command
+			defaultRunFunc := cmdutil.DefaultSubCommandRun(out)
+			defaultRunFunc(cmd, args)
+			return
+		},
+		Aliases: []string{},
+	}
+
+	// sub command
+	cmd.AddCommand(NewCmdRoleCreate(f, out, cmdErr))
+	cmd.AddCommand(NewCmdRoleBind(f, out, cmdErr))
+	cmd.AddCommand(NewCmdRoleUnbind(f, out, cmdErr))
+	cmd.AddCommand(NewCmdRoleList(f, out, cmdErr))
+
+	return cmd
+}
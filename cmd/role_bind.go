@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	roleBindExample = templates.Examples(i18n.T(`
+	# Grant the role admin to user lkong
+	cmdctl role bind admin lkong`))
+
+	roleUnbindExample = templates.Examples(i18n.T(`
+	# Revoke the role admin from user lkong
+	cmdctl role unbind admin lkong`))
+)
+
+func NewCmdRoleBind(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "bind ROLE USERNAME...",
+		Short:   i18n.T("Grant a role to users"),
+		Long:    "Grant a role to users",
+		Example: roleBindExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunRoleBind(f, out, cmdErr, cmd, args, true))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionRoleManage)
+}
+
+func NewCmdRoleUnbind(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "unbind ROLE USERNAME...",
+		Short:   i18n.T("Revoke a role from users"),
+		Long:    "Revoke a role from users",
+		Example: roleUnbindExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunRoleBind(f, out, cmdErr, cmd, args, false))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionRoleManage)
+}
+
+// RunRoleBind grants the role to the users if bind is true, otherwise revokes it.
+func RunRoleBind(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string, bind bool) error {
+	if len(args) < 2 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	if err := model.DB.Init(); err != nil {
+		return err
+	}
+
+	role := args[0]
+	if _, err := model.GetRole(role); err != nil {
+		if model.IsNotFound(err) {
+			return fmt.Errorf("role %q not found", role)
+		}
+		return err
+	}
+
+	var failed bool
+	for _, username := range args[1:] {
+		var err error
+		if bind {
+			err = model.BindRole(role, username)
+		} else {
+			err = model.UnbindRole(role, username)
+		}
+		if model.IsNotFound(err) {
+			err = fmt.Errorf("user %q not found", username)
+		}
+		if err != nil {
+			fmt.Fprintf(cmdErr, "Error: %v\n", err)
+			failed = true
+			continue
+		}
+
+		if bind {
+			fmt.Fprintf(out, "role %q bound to user %q\n", role, username)
+		} else {
+			fmt.Fprintf(out, "role %q unbound from user %q\n", role, username)
+		}
+	}
+
+	if failed {
+		return cmdutil.ErrExit
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+type RoleCreateOptions struct {
+	description string
+	permissions []string
+}
+
+var (
+	roleCreateExample = templates.Examples(i18n.T(`
+	# Create a role which can do everything
+	cmdctl role create admin --permission '*'
+
+	# Create a role which can create and update users
+	cmdctl role create operator --permission user:create,user:update --description "user operators"
+
+	# Create a role which can do everything on users
+	cmdctl role create useradmin --permission 'user:*'`))
+
+	permissionRegexp = regexp.MustCompile(`^(\*|[a-z]+:(\*|[a-z]+))$`)
+)
+
+func NewCmdRoleCreate(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "create NAME --permission PERMISSION,...",
+		Short:   i18n.T("Create a role with permissions"),
+		Long:    "Create a role with permissions, a permission is named as resource:verb, resource:* or *",
+		Example: roleCreateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args))
+			}
+			options := new(RoleCreateOptions)
+			cmdutil.CheckErr(options.Complete(cmd))
+			if err := options.Validate(); err != nil {
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "%s", err))
+			}
+			cmdutil.CheckErr(options.Run(f, out, cmdErr, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmd.Flags().StringSlice("permission", []string{}, "The permissions granted by the role.")
+	cmd.Flags().String("description", "", "Specify the role description.")
+	return cmdutil.SetPermission(cmd, PermissionRoleManage)
+}
+
+func (o *RoleCreateOptions) Run(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, args []string) error {
+	if err := model.DB.Init(); err != nil {
+		return err
+	}
+
+	if err := model.CreateRole(args[0], o.description, o.permissions); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "role %q created\n", args[0])
+	return nil
+}
+
+func (o *RoleCreateOptions) Complete(cmd *cobra.Command) error {
+	o.description = cmdutil.GetFlagString(cmd, "description")
+	o.permissions = cmdutil.GetFlagStringSlice(cmd, "permission")
+	return nil
+}
+
+func (o *RoleCreateOptions) Validate() error {
+	if len(o.permissions) == 0 {
+		return fmt.Errorf("at least one --permission must be specified")
+	}
+	for _, p := range o.permissions {
+		if !permissionRegexp.MatchString(p) {
+			return fmt.Errorf("%q is not a valid permission, must be resource:verb, resource:* or *", p)
+		}
+	}
+
+	return nil
+}
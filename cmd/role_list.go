@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"io"
+	"strings"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// roleOutput is the form of a role printed by -o json and -o yaml.
+type roleOutput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+	Users       []string `json:"users"`
+}
+
+var (
+	roleListExample = templates.Examples(i18n.T(`
+	# List the roles with their permissions and users
+	cmdctl role list
+
+	# List the roles as yaml
+	cmdctl role list -o yaml`))
+)
+
+func NewCmdRoleList(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   i18n.T("List the roles with their permissions and users"),
+		Long:    "List the roles with their permissions and users",
+		Example: roleListExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunRoleList(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{"li"},
+	}
+
+	cmdutil.AddOutputFlag(cmd, "")
+	cmdutil.AddTableFlags(cmd, true)
+	return cmd
+}
+
+func RunRoleList(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	printer, err := cmdutil.PrinterForCommand(cmd)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	if err := model.DB.Init(); err != nil {
+		return err
+	}
+
+	roles, err := model.ListRoles()
+	if err != nil {
+		return err
+	}
+
+	objects := make([]*roleOutput, 0, len(roles))
+	rows := make([]cmdutil.Row, 0, len(roles))
+	for _, role := range roles {
+		objects = append(objects, &roleOutput{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+			Users:       role.Users,
+		})
+		rows = append(rows, cmdutil.Row{
+			Name:  role.Name,
+			Cells: []string{role.Name, strings.Join(role.Permissions, ","), strings.Join(role.Users, ","), role.Description},
+		})
+	}
+
+	return printer.PrintObj(&cmdutil.Printable{
+		Kind:   "role",
+		Object: objects,
+		Columns: []cmdutil.Column{
+			{Name: "Role"},
+			{Name: "Permissions"},
+			{Name: "Users"},
+			{Name: "Description"},
+		},
+		Rows: rows,
+	}, out)
+}
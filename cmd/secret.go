@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"io"
+
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdSecret(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret SUBCOMMAND",
+		Short: i18n.T("Manage the encrypted secrets referred to by the config"),
+		Long:  "Manage the secrets kept in ~/.cmdctl/secrets, which is encrypted by the key ~/.cmdctl/secrets.key. The config refers to a secret as secret:NAME in place of a password, such as `db.password: secret:db`",
+		Run: func(cmd *cobra.Command, args []string) {
+			// run sub command
+			defaultRunFunc := cmdutil.DefaultSubCommandRun(out)
+			defaultRunFunc(cmd, args)
+			return
+		},
+		Aliases: []string{},
+	}
+
+	// sub command
+	cmd.AddCommand(NewCmdSecretSet(f, in, out, cmdErr))
+	cmd.AddCommand(NewCmdSecretGet(f, out, cmdErr))
+	cmd.AddCommand(NewCmdSecretRm(f, out, cmdErr))
+
+	return cmd
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+	"cmdctl/pkg/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretGetExample = templates.Examples(i18n.T(`
+	# Print the secret db
+	cmdctl secret get db`))
+)
+
+func NewCmdSecretGet(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "get NAME",
+		Short:   i18n.T("Print a secret"),
+		Long:    "Print a secret of the encrypted secrets file",
+		Example: secretGetExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunSecretGet(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmd
+}
+
+func RunSecretGet(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	value, err := secret.DefaultStore().Get(args[0])
+	if err == secret.ErrNotFound {
+		return fmt.Errorf("secret %q not found", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, value)
+	return nil
+}
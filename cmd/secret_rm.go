@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+	"cmdctl/pkg/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretRmExample = templates.Examples(i18n.T(`
+	# Remove the secret db
+	cmdctl secret rm db`))
+)
+
+func NewCmdSecretRm(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm NAME...",
+		Short:   i18n.T("Remove secrets"),
+		Long:    "Remove secrets from the encrypted secrets file",
+		Example: secretRmExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunSecretRm(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{"delete"},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionSecretManage)
+}
+
+func RunSecretRm(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmdutil.UsageErrorf(cmd, "NAME is required")
+	}
+
+	store := secret.DefaultStore()
+	for _, name := range args {
+		ok, err := store.Delete(name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("secret %q not found", name)
+		}
+		fmt.Fprintf(out, "Secret %q removed.\n", name)
+	}
+	return nil
+}
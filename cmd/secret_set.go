@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"cmdctl/cmd/templates"
+	cmdterm "cmdctl/cmd/term"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+	"cmdctl/pkg/interrupt"
+	"cmdctl/pkg/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretSetExample = templates.Examples(i18n.T(`
+	# Set the secret db, the value is prompted for
+	cmdctl secret set db
+
+	# Set the secret db from a file
+	cmdctl secret set db --stdin < db-password.txt
+
+	# Use the secret as the password of the db
+	cmdctl config set db.password secret:db`))
+)
+
+func NewCmdSecretSet(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "set NAME [VALUE]",
+		Short:   i18n.T("Set a secret"),
+		Long:    "Set a secret in the encrypted secrets file, the key is generated by the first secret. The value is prompted for if omitted",
+		Example: secretSetExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunSecretSet(f, in, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmd.Flags().Bool("stdin", false, "Read the value from stdin, the trailing newline is removed.")
+	return cmdutil.SetPermission(cmdutil.SetSecretArgs(cmd, 1), PermissionSecretManage)
+}
+
+func RunSecretSet(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	if len(args) == 2 && cmdutil.GetFlagBool(cmd, "stdin") {
+		return cmdutil.UsageErrorf(cmd, "--stdin can not be used with the VALUE argument")
+	}
+
+	name := args[0]
+	if err := secret.ValidateName(name); err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	var value string
+	switch {
+	case len(args) == 2:
+		value = args[1]
+	case cmdutil.GetFlagBool(cmd, "stdin"):
+		data, err := ioutil.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	default:
+		tty := cmdterm.TTY{
+			In:  in,
+			Out: cmdErr,
+			// the terminal is restored first, then the exit hooks run
+			Parent: interrupt.New(func(os.Signal) {
+				cmdutil.Exit(InterruptedExitCode)
+			}),
+		}
+		var err error
+		value, err = tty.ReadNewSecret("Value: ", "Confirm value: ")
+		if err == cmdterm.ErrNotTerminal {
+			return fmt.Errorf("stdin is not a terminal, give the value by --stdin")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if value == "" {
+		return fmt.Errorf("the value of a secret can not be empty")
+	}
+
+	if err := secret.DefaultStore().Set(name, value); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Secret %q set, refer to it as secret:%s in the config.\n", name, name)
+	return nil
+}
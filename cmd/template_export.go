@@ -40,7 +40,7 @@ func NewCmdTemplateExport(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *c
 			options := new(exportOptions)
 			cmdutil.CheckErr(options.Complete(cmd))
 			if err := options.Validate(); err != nil {
-				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, err.Error()))
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "%s", err))
 			}
 			cmdutil.CheckErr(options.Run(f, out, cmdErr, cmd, args))
 			return
@@ -39,7 +39,7 @@ func NewCmdTemplateImport(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *c
 			options := new(ImportOptions)
 			cmdutil.CheckErr(options.Complete(cmd))
 			if err := options.Validate(); err != nil {
-				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, err.Error()))
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "%s", err))
 			}
 			cmdutil.CheckErr(options.Run(f, out, cmdErr, args))
 			return
@@ -51,7 +51,7 @@ func NewCmdTemplateImport(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *c
 	cmd.Flags().BoolP("user", "", false, "Specify the create username.")
 	cmd.Flags().IntP("appId", "", 0, "AppId to use")
 
-	return cmd
+	return cmdutil.SetPermission(cmd, PermissionTemplateImport)
 }
 
 func validateArgs(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,105 @@
+package term
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"cmdctl/pkg/interrupt"
+	"cmdctl/pkg/term"
+)
+
+var (
+	// ErrNotTerminal is returned when a secret is prompted for but the input is not a terminal.
+	ErrNotTerminal = errors.New("input is not a terminal")
+	// ErrSecretMismatch is returned when the confirmation differs from the secret.
+	ErrSecretMismatch = errors.New("the two inputs do not match")
+)
+
+// ReadSecret writes the prompt to t.Out and reads a line from t.In with echo
+// disabled. The terminal is restored when it returns or the process is interrupted.
+func (t TTY) ReadSecret(prompt string) (string, error) {
+	fd, isTerminal := term.GetFdInfo(t.In)
+	if !isTerminal {
+		return "", ErrNotTerminal
+	}
+
+	// wrap the parent so that it is only consumed by a signal, not by the
+	// prompt returning, since a TTY may prompt several times
+	if t.Parent != nil {
+		t.Parent = interrupt.New(t.Parent.Signal)
+	}
+
+	var secret string
+	err := t.Safe(func() error {
+		state, err := term.SaveState(fd)
+		if err != nil {
+			return err
+		}
+		if err := term.DisableEcho(fd, state); err != nil {
+			return err
+		}
+
+		fmt.Fprint(t.out(), prompt)
+		secret, err = ReadLine(t.In)
+		// the newline typed by the user is not echoed
+		fmt.Fprintln(t.out())
+		return err
+	})
+	return secret, err
+}
+
+// ReadNewSecret reads a secret twice with ReadSecret, and fails if the two
+// inputs differ or the secret is empty.
+func (t TTY) ReadNewSecret(prompt, confirmPrompt string) (string, error) {
+	secret, err := t.ReadSecret(prompt)
+	if err != nil {
+		return "", err
+	}
+	if secret == "" {
+		return "", errors.New("the input can not be empty")
+	}
+
+	confirm, err := t.ReadSecret(confirmPrompt)
+	if err != nil {
+		return "", err
+	}
+	if confirm != secret {
+		return "", ErrSecretMismatch
+	}
+
+	return secret, nil
+}
+
+func (t TTY) out() io.Writer {
+	if t.Out == nil {
+		return ioutil.Discard
+	}
+	return t.Out
+}
+
+// ReadLine reads a line without the line ending. It reads one byte at a time
+// so nothing after the line is consumed from r.
+func ReadLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err == io.EOF && len(line) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
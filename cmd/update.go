@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+	"cmdctl/pkg/validation"
+
+	"github.com/spf13/cobra"
+)
+
+type UpdateOptions struct {
+	email       string
+	password    string
+	setEmail    bool
+	setPassword bool
+	attributes  model.Attributes
+	removeAttrs []string
+}
+
+var (
+	updateExample = templates.Examples(i18n.T(`
+		# Update the email of user lkong
+		cmdctl update user lkong --email 466701708@qq.com
+
+		# Reset the password of user lkong, the new password is prompted for
+		cmdctl update user lkong --password
+
+		# Reset the password of user lkong with the password read from stdin
+		cat passwd.txt | cmdctl update user lkong --password-stdin
+
+		# Set the team of user lkong and remove the tier
+		cmdctl update user lkong --attr team=infra --remove-attr tier`))
+)
+
+func NewCmdUpdate(f cmdutil.Factory, in io.Reader, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "update user NAME",
+		Short:   i18n.T("Update the email, password or attributes of a user"),
+		Long:    "Update the email, password or attributes of a user",
+		Example: updateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(validateUpdateArgs(cmd, args))
+			options := new(UpdateOptions)
+			cmdutil.CheckErr(options.Complete(cmd, in, cmdErr))
+			if err := options.Validate(); err != nil {
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "%s", err))
+			}
+			cmdutil.CheckErr(options.RunUpdate(f, out, cmdErr, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmd.Flags().StringP("email", "e", "", "Specify the new user email.")
+	cmd.Flags().BoolP("password", "p", false, "Prompt for the new user password.")
+	addPasswordStdinFlag(cmd)
+	cmd.Flags().StringArray("attr", []string{}, "Set a custom attribute as key=value, can be repeated.")
+	cmd.Flags().StringSlice("remove-attr", []string{}, "Remove the custom attributes with the keys.")
+	return cmdutil.SetPermission(cmd, PermissionUserUpdate)
+}
+
+func validateUpdateArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 3 && cmdutil.GetFlagBool(cmd, "password") {
+		return cmdutil.UsageErrorf(cmd, "--password no longer takes the password, it is prompted for or read by --password-stdin")
+	}
+	if len(args) != 2 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+
+	return validateUserResource(cmd, args[0])
+}
+
+func (o *UpdateOptions) RunUpdate(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, args []string) error {
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	user, err := store.Get(args[1])
+	if model.IsNotFound(err) {
+		return fmt.Errorf("user %q not found", args[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	if o.setEmail {
+		user.Email = o.email
+	}
+	if o.setPassword {
+		user.Password = o.password
+	}
+	if len(o.attributes) > 0 || len(o.removeAttrs) > 0 {
+		if user.Attributes == nil {
+			user.Attributes = make(model.Attributes)
+		}
+		for k, v := range o.attributes {
+			user.Attributes[k] = v
+		}
+		for _, k := range o.removeAttrs {
+			delete(user.Attributes, k)
+		}
+	}
+
+	if err := store.Update(user); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "user %q updated\n", user.Username)
+	return nil
+}
+
+func (o *UpdateOptions) Complete(cmd *cobra.Command, in io.Reader, cmdErr io.Writer) error {
+	o.email = cmdutil.GetFlagString(cmd, "email")
+	o.setEmail = cmd.Flags().Changed("email")
+	o.setPassword = cmdutil.GetFlagBool(cmd, "password") || cmdutil.GetFlagBool(cmd, "password-stdin")
+	o.removeAttrs = cmdutil.GetFlagStringSlice(cmd, "remove-attr")
+
+	var err error
+	if o.attributes, err = parseAttributes(cmdutil.GetFlagStringArray(cmd, "attr")); err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	if o.setPassword {
+		o.password, err = readPassword(cmd, in, cmdErr, true)
+	}
+	return err
+}
+
+func (o *UpdateOptions) Validate() error {
+	if !o.setEmail && !o.setPassword && len(o.attributes) == 0 && len(o.removeAttrs) == 0 {
+		return fmt.Errorf("at least one of --email, --password, --password-stdin, --attr or --remove-attr must be specified")
+	}
+	for _, k := range o.removeAttrs {
+		if _, ok := o.attributes[k]; ok {
+			return fmt.Errorf("attribute %q can not be both set and removed", k)
+		}
+	}
+
+	// only the changed fields are checked against the rules of model.UserModel
+	fields := make([]string, 0, 2)
+	if o.setEmail {
+		fields = append(fields, "Email")
+	}
+	if o.setPassword {
+		fields = append(fields, "Password")
+	}
+	return validation.ValidateFields(&model.UserModel{Email: o.email, Password: o.password}, fields...)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"io"
+
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// userRecord is the portable form of a user read by `user import` and
+// written by `user export`.
+type userRecord struct {
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	Email    string `json:"email,omitempty" yaml:"email,omitempty"`
+	// Attributes are written to the csv format as a json object.
+	Attributes model.Attributes `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// userRecordFields are the column names of the csv format, in order.
+var userRecordFields = []string{"username", "password", "email", "attributes"}
+
+func NewCmdUser(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user SUBCOMMAND",
+		Short: i18n.T("Import, export and maintain users in bulk"),
+		Long:  "Import, export and maintain users in bulk",
+		Run: func(cmd *cobra.Command, args []string) {
+			// run sub command
+			defaultRunFunc := cmdutil.DefaultSubCommandRun(out)
+			defaultRunFunc(cmd, args)
+			return
+		},
+		Aliases: []string{"us"},
+	}
+
+	// sub command
+	cmd.AddCommand(NewCmdUserImport(f, out, cmdErr))
+	cmd.AddCommand(NewCmdUserExport(f, out, cmdErr))
+	cmd.AddCommand(NewCmdUserRestore(f, out, cmdErr))
+	cmd.AddCommand(NewCmdUserPurge(f, out, cmdErr))
+
+	return cmd
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+var (
+	userExportExample = templates.Examples(i18n.T(`
+	# Export all users as csv
+	cmdctl user export > users.csv
+
+	# Export all users as yaml
+	cmdctl user export -o yaml > users.yaml`))
+)
+
+func NewCmdUserExport(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   i18n.T("Export users as csv, json or yaml"),
+		Long:    "Export users as csv, json or yaml, the passwords are exported as hashes so the output can be imported again",
+		Example: userExportExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunUserExport(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{"exp"},
+	}
+
+	cmd.Flags().StringP("output", "o", "csv", "One of 'csv', 'json' or 'yaml'.")
+	return cmd
+}
+
+func RunUserExport(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	output := cmdutil.GetFlagString(cmd, "output")
+	if output != "csv" && output != "json" && output != "yaml" {
+		return cmdutil.UsageErrorf(cmd, "--output must be 'csv', 'json' or 'yaml'")
+	}
+
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// oldest first, so the users are imported in the same order
+	users, _, err := store.List(&model.ListUserOptions{SortBy: "createdAt"})
+	if err != nil {
+		return err
+	}
+
+	records := make([]*userRecord, 0, len(users))
+	for _, u := range users {
+		records = append(records, &userRecord{Username: u.Username, Password: u.Password, Email: u.Email, Attributes: u.Attributes})
+	}
+
+	switch output {
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Write(userRecordFields)
+		for _, r := range records {
+			var attributes string
+			if len(r.Attributes) > 0 {
+				data, err := json.Marshal(r.Attributes)
+				if err != nil {
+					return err
+				}
+				attributes = string(data)
+			}
+			w.Write([]string{r.Username, r.Password, r.Email, attributes})
+		}
+		w.Flush()
+		return w.Error()
+	case "json":
+		marshalled, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(marshalled))
+	case "yaml":
+		marshalled, err := yaml.Marshal(records)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, string(marshalled))
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+	"cmdctl/pkg/selector"
+	"cmdctl/pkg/validation"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+type UserImportOptions struct {
+	filename        string                 `flag:"filename" validate:"required"`
+	format          string                 `flag:"format" validate:"required,oneof=csv json yaml"`
+	conflict        model.ConflictStrategy `flag:"conflict" validate:"required,oneof=skip overwrite fail"`
+	continueOnError bool
+}
+
+// lineRecord is a user record along with the line it starts at.
+type lineRecord struct {
+	userRecord
+	line int
+}
+
+var (
+	userImportExample = templates.Examples(i18n.T(`
+	# Import users from a csv file, the first line is the header: username,password,email,attributes
+	# the attributes column is optional and holds a json object such as {"team":"infra"}
+	cmdctl user import -f users.csv
+
+	# Import users from a yaml file, overwrite the existing users
+	cmdctl user import -f users.yaml --conflict overwrite
+
+	# Import the valid users and report the invalid ones
+	cmdctl user import -f users.json --continue-on-error`))
+)
+
+func NewCmdUserImport(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "import -f FILENAME",
+		Short:   i18n.T("Import users from a csv, json or yaml file"),
+		Long:    "Import users from a csv, json or yaml file in one transaction",
+		Example: userImportExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options := new(UserImportOptions)
+			cmdutil.CheckErr(options.Complete(cmd))
+			if err := options.Validate(); err != nil {
+				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "%s", err))
+			}
+			cmdutil.CheckErr(options.Run(f, out, cmdErr, args))
+			return
+		},
+		Aliases: []string{"imp"},
+	}
+
+	cmd.Flags().StringP("filename", "f", "", "The file to import users from.")
+	cmd.Flags().String("format", "", "One of 'csv', 'json' or 'yaml', detected from the file extension by default.")
+	cmd.Flags().String("conflict", string(model.ConflictFail), "What to do with existing usernames, one of 'skip', 'overwrite' or 'fail'.")
+	cmd.Flags().Bool("continue-on-error", false, "Import the valid rows and report the invalid ones instead of aborting.")
+
+	return cmdutil.SetPermission(cmd, PermissionUserImport)
+}
+
+func (o *UserImportOptions) Run(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, args []string) error {
+	data, err := ioutil.ReadFile(o.filename)
+	if err != nil {
+		return err
+	}
+
+	var records []*lineRecord
+	switch o.format {
+	case "csv":
+		records, err = decodeCSVRecords(data)
+	case "json":
+		records, err = decodeJSONRecords(data)
+	case "yaml":
+		records, err = decodeYAMLRecords(data)
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s failed: %v", o.filename, err)
+	}
+
+	// validate all rows first, so that every invalid row is reported at once
+	users := make([]*model.UserModel, 0, len(records))
+	lines := make([]int, 0, len(records))
+	seen := make(map[string]int)
+	var invalid int
+	for _, r := range records {
+		err := validateUserRecord(&r.userRecord)
+		if err == nil {
+			if line, ok := seen[r.Username]; ok {
+				err = fmt.Errorf("duplicate username %q, first defined at line %d", r.Username, line)
+			}
+		}
+		if errs, ok := err.(validation.Errors); ok {
+			for _, e := range errs {
+				fmt.Fprintf(cmdErr, "line %d: %v\n", r.line, e)
+			}
+		} else if err != nil {
+			fmt.Fprintf(cmdErr, "line %d: %v\n", r.line, err)
+		}
+		if err != nil {
+			invalid++
+			continue
+		}
+
+		seen[r.Username] = r.line
+		users = append(users, &model.UserModel{Username: r.Username, Password: r.Password, Email: r.Email, Attributes: r.Attributes})
+		lines = append(lines, r.line)
+	}
+	if invalid > 0 && !o.continueOnError {
+		return fmt.Errorf("%d invalid row(s) found, no user imported", invalid)
+	}
+
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	result, err := store.Import(users, o.conflict, func(i int, err error) bool {
+		fmt.Fprintf(cmdErr, "line %d: %v\n", lines[i], err)
+		return o.continueOnError
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%d created, %d updated, %d skipped, %d failed\n",
+		result.Created, result.Updated, result.Skipped, result.Failed+invalid)
+	return nil
+}
+
+func (o *UserImportOptions) Complete(cmd *cobra.Command) error {
+	o.filename = cmdutil.GetFlagString(cmd, "filename")
+	o.format = cmdutil.GetFlagString(cmd, "format")
+	o.conflict = model.ConflictStrategy(cmdutil.GetFlagString(cmd, "conflict"))
+	o.continueOnError = cmdutil.GetFlagBool(cmd, "continue-on-error")
+
+	if o.format == "" {
+		o.format = strings.TrimPrefix(strings.ToLower(filepath.Ext(o.filename)), ".")
+		if o.format == "yml" {
+			o.format = "yaml"
+		}
+	}
+	return nil
+}
+
+func (o *UserImportOptions) Validate() error {
+	return validation.Validate(o)
+}
+
+// validateUserRecord runs the same checks as `cmdctl add`.
+func validateUserRecord(r *userRecord) error {
+	keys := make([]string, 0, len(r.Attributes))
+	for k := range r.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := selector.ValidateKey(k); err != nil {
+			return err
+		}
+	}
+	return validation.Validate(&model.UserModel{Username: r.Username, Password: r.Password, Email: r.Email})
+}
+
+// decodeCSVRecords reads the csv data, the first line is the header naming the columns.
+func decodeCSVRecords(data []byte) ([]*lineRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("line 1: the header must contain a 'username' column")
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	records := make([]*lineRecord, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		var attributes model.Attributes
+		if s := field(row, "attributes"); s != "" {
+			if err := json.Unmarshal([]byte(s), &attributes); err != nil {
+				return nil, fmt.Errorf("line %d: invalid attributes, must be a json object of strings: %v", line, err)
+			}
+		}
+		records = append(records, &lineRecord{
+			userRecord: userRecord{
+				Username:   field(row, "username"),
+				Password:   field(row, "password"),
+				Email:      field(row, "email"),
+				Attributes: attributes,
+			},
+			line: line,
+		})
+	}
+
+	return records, nil
+}
+
+// decodeJSONRecords reads a json array of users, the decoder offsets are used
+// to find the line of every element.
+func decodeJSONRecords(data []byte) ([]*lineRecord, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if t, err := decoder.Token(); err != nil || t != json.Delim('[') {
+		return nil, fmt.Errorf("line %d: a json array of users is expected", lineOf(data, 0))
+	}
+
+	records := make([]*lineRecord, 0)
+	for decoder.More() {
+		line := lineOf(data, int(decoder.InputOffset()))
+		r := &lineRecord{line: line}
+		if err := decoder.Decode(&r.userRecord); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+// lineOf returns the line of the first value at or after offset, the
+// whitespaces and commas between values are skipped.
+func lineOf(data []byte, offset int) int {
+	for offset < len(data) && strings.IndexByte(" \t\r\n,", data[offset]) >= 0 {
+		offset++
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// decodeYAMLRecords reads a yaml sequence of users, the yaml nodes carry their lines.
+func decodeYAMLRecords(data []byte) ([]*lineRecord, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	records := make([]*lineRecord, 0)
+	if len(doc.Content) == 0 {
+		return records, nil
+	}
+
+	seq := doc.Content[0]
+	if seq.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: a yaml sequence of users is expected", seq.Line)
+	}
+
+	for _, node := range seq.Content {
+		r := &lineRecord{line: node.Line}
+		if err := node.Decode(&r.userRecord); err != nil {
+			return nil, fmt.Errorf("line %d: %v", node.Line, err)
+		}
+		records = append(records, r)
+	}
+
+	return records, nil
+}
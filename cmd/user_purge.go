@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	userPurgeExample = templates.Examples(i18n.T(`
+	# Show the users deleted more than 30 days ago without purging them
+	cmdctl user purge --older-than 30d --dry-run
+
+	# Permanently delete the users deleted more than 30 days ago
+	cmdctl user purge --older-than 30d`))
+)
+
+func NewCmdUserPurge(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "purge --older-than DURATION",
+		Short:   i18n.T("Permanently delete users deleted long ago"),
+		Long:    "Permanently delete users deleted long ago, purged users can not be restored",
+		Example: userPurgeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunUserPurge(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	cmd.Flags().String("older-than", "30d", "Purge the users deleted earlier than this, e.g. 30d or 12h.")
+	cmd.Flags().Bool("dry-run", false, "Only print the users which would be purged.")
+	return cmdutil.SetPermission(cmd, PermissionUserPurge)
+}
+
+func RunUserPurge(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	age, err := parseAge(cmdutil.GetFlagString(cmd, "older-than"))
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "--older-than: %v", err)
+	}
+	dryRun := cmdutil.GetFlagBool(cmd, "dry-run")
+
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	users, err := store.Purge(time.Now().Add(-age), dryRun)
+	if err != nil {
+		return err
+	}
+
+	action := "purged"
+	if dryRun {
+		action = "would be purged (dry run)"
+	}
+	for _, user := range users {
+		fmt.Fprintf(out, "user %q deleted %s ago %s\n", user.Username, translateTimestamp(*user.DeletedAt), action)
+	}
+	fmt.Fprintf(out, "%d user(s) %s\n", len(users), action)
+	return nil
+}
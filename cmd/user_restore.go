@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"cmdctl/cmd/templates"
+	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
+	"cmdctl/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	userRestoreExample = templates.Examples(i18n.T(`
+	# Restore the deleted user lkong
+	cmdctl user restore lkong`))
+)
+
+func NewCmdUserRestore(f cmdutil.Factory, out io.Writer, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "restore NAME...",
+		Short:   i18n.T("Restore deleted users"),
+		Long:    "Restore deleted users, the latest deleted one is restored if several users with the same name were deleted",
+		Example: userRestoreExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunUserRestore(f, out, cmdErr, cmd, args))
+			return
+		},
+		Aliases: []string{},
+	}
+
+	return cmdutil.SetPermission(cmd, PermissionUserRestore)
+}
+
+func RunUserRestore(f cmdutil.Factory, out io.Writer, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmdutil.UsageErrorf(cmd, "at least one user name must be specified")
+	}
+
+	store, err := f.UserStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var failed bool
+	for _, name := range args {
+		err := store.Restore(name)
+		if model.IsNotFound(err) {
+			err = fmt.Errorf("deleted user %q not found", name)
+		}
+		if err != nil {
+			fmt.Fprintf(cmdErr, "Error: %v\n", err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(out, "user %q restored\n", name)
+	}
+
+	if failed {
+		return cmdutil.ErrExit
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	exitHooks    []func(code int)
+	exitHooksMux sync.Mutex
+	exitOnce     sync.Once
+)
+
+// OnExit registers fn to be called with the exit code before cmdctl exits,
+// the hooks are called in the reverse order of registration, like defers.
+func OnExit(fn func(code int)) {
+	exitHooksMux.Lock()
+	defer exitHooksMux.Unlock()
+	exitHooks = append(exitHooks, fn)
+}
+
+// RunExitHooks calls the registered exit hooks, only the first call takes effect.
+func RunExitHooks(code int) {
+	exitOnce.Do(func() {
+		exitHooksMux.Lock()
+		hooks := exitHooks
+		exitHooksMux.Unlock()
+
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hooks[i](code)
+		}
+	})
+}
+
+// Exit runs the exit hooks and exits with the code.
+func Exit(code int) {
+	RunExitHooks(code)
+	os.Exit(code)
+}
@@ -8,9 +8,13 @@ import (
 	"strings"
 	"time"
 
+	"cmdctl/model"
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/secret"
+
 	"github.com/parnurzeal/gorequest"
+	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 )
 
 type FileServer struct {
@@ -37,16 +41,22 @@ func (f *Factory) FlagSet() *pflag.FlagSet {
 	return f.flags
 }
 
-// TODO: We need to filter out stuff like secrets.
-func (f *Factory) Command() string {
+// Command returns the command line used to run cmd, the secrets such as
+// passwords are redacted.
+func (f *Factory) Command(cmd *cobra.Command) string {
 	if len(os.Args) == 0 {
 		return ""
 	}
 	base := filepath.Base(os.Args[0])
-	args := append([]string{base}, os.Args[1:]...)
+	args := append([]string{base}, RedactArgs(cmd, os.Args[1:])...)
 	return strings.Join(args, " ")
 }
 
+// UserStore opens the user store of the self datasource, the caller should close it.
+func (f *Factory) UserStore() (model.UserStore, error) {
+	return model.NewUserStore(model.SelfDataSource)
+}
+
 func (f *Factory) BindFlags(flags *pflag.FlagSet) {
 	// Merge factory's flags
 	flags.AddFlagSet(f.flags)
@@ -57,8 +67,15 @@ func (f *Factory) BindExternalFlags(flags *pflag.FlagSet) {
 	flags.AddGoFlagSet(flag.CommandLine)
 }
 
-func (f *Factory) Auth() string {
-	return base64.StdEncoding.EncodeToString([]byte(f.FileServer().Username + ":" + f.FileServer().Password))
+// Auth returns the basic auth credentials of the file server, the password
+// may be a secret reference, see secret.Resolve.
+func (f *Factory) Auth() (string, error) {
+	server := f.FileServer()
+	password, err := secret.Resolve(server.Password)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(server.Username + ":" + password)), nil
 }
 
 func (f *Factory) Gorequest() *gorequest.SuperAgent {
@@ -69,11 +86,31 @@ func (f *Factory) Gorequest() *gorequest.SuperAgent {
 		Set("Username", "cc")
 }
 
+// FileServer returns the file server of the current context, the pieces not
+// set by the context are read from the `fileserver` section.
 func (f *Factory) FileServer() *FileServer {
-	return &FileServer{
-		Server:   viper.GetString("fileserver.server"),
-		Timeout:  viper.GetInt("fileserver.timeout"),
-		Username: viper.GetString("fileserver.username"),
-		Password: viper.GetString("fileserver.password"),
+	c := config.Get().FileServer
+	server := &FileServer{
+		Server:   c.Server,
+		Timeout:  c.Timeout,
+		Username: c.Username,
+		Password: c.Password,
+	}
+
+	// the errors are reported by the root command before running
+	context, err := config.CurrentContext()
+	if err != nil {
+		return server
+	}
+	if context.Server != nil {
+		server.Server = context.Server.Server
+		if context.Server.Timeout > 0 {
+			server.Timeout = context.Server.Timeout
+		}
+	}
+	if context.Credential != nil {
+		server.Username = context.Credential.Username
+		server.Password = context.Credential.Password
 	}
+	return server
 }
@@ -45,7 +45,7 @@ func fatal(msg string, code int) {
 		}
 		fmt.Fprint(os.Stderr, msg)
 	}
-	os.Exit(code)
+	Exit(code)
 }
 
 var ErrExit = fmt.Errorf("exit")
@@ -67,6 +67,8 @@ func checkErr(prefix string, err error, handleErr func(string, int)) {
 		return
 	default:
 		switch err := err.(type) {
+		case *PermissionDeniedError:
+			handleErr(color.RedString("error: %s", err.Error()), PermissionDeniedExitCode)
 		default: // for any other error type
 			msg, ok := StandardErrorMessage(err)
 			if !ok {
@@ -211,6 +213,6 @@ func DefaultSubCommandRun(out io.Writer) func(c *cobra.Command, args []string) {
 
 func RequireNoArguments(c *cobra.Command, args []string) {
 	if len(args) > 0 {
-		CheckErr(UsageErrorf(c, fmt.Sprintf(`unknown command %q`, strings.Join(args, " "))))
+		CheckErr(UsageErrorf(c, "unknown command %q", strings.Join(args, " ")))
 	}
 }
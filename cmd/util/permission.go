@@ -0,0 +1,45 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// PermissionAnnotation is the command annotation naming the permission
+	// required to run a mutating command.
+	PermissionAnnotation = "cmdctl/permission"
+
+	// PermissionDeniedExitCode is the exit code used when the acting user is not
+	// allowed to run a command, the same as EX_NOPERM in sysexits.h.
+	PermissionDeniedExitCode = 77
+)
+
+// PermissionDeniedError is returned when the acting user lacks the permission
+// required by a command.
+type PermissionDeniedError struct {
+	User       string
+	Permission string
+}
+
+func (e *PermissionDeniedError) Error() string {
+	if e.User == "" {
+		return fmt.Sprintf("permission %q is required but no user is logged in, run 'cmdctl login' first", e.Permission)
+	}
+	return fmt.Sprintf("user %q does not have the permission %q", e.User, e.Permission)
+}
+
+// SetPermission marks cmd as a mutating command which requires the permission.
+func SetPermission(cmd *cobra.Command, permission string) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[PermissionAnnotation] = permission
+	return cmd
+}
+
+// GetPermission returns the permission required by cmd, empty if cmd is not mutating.
+func GetPermission(cmd *cobra.Command) string {
+	return cmd.Annotations[PermissionAnnotation]
+}
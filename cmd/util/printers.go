@@ -0,0 +1,268 @@
+package util
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// Column is a column of the tabular output.
+type Column struct {
+	Name string
+	// Wide columns are only printed by -o wide, csv and tsv.
+	Wide bool
+	// Color decorates the cells of the column in the table output, it is
+	// never applied to the machine-readable formats.
+	Color func(value string) string
+}
+
+// Row is a row of the tabular output, it has a cell per column.
+type Row struct {
+	// Name identifies the row in the -o name output.
+	Name  string
+	Cells []string
+	// Color decorates the whole row in the table output, in place of the
+	// colors of the columns.
+	Color func(value string) string
+}
+
+// Printable is the output of a command in a form every printer understands.
+type Printable struct {
+	// Kind prefixes the names in the -o name output, such as user/lkong.
+	Kind string
+	// Object is marshalled by the json and yaml printers.
+	Object  interface{}
+	Columns []Column
+	Rows    []Row
+}
+
+// ResourcePrinter prints the output of a command in a format.
+type ResourcePrinter interface {
+	PrintObj(obj *Printable, w io.Writer) error
+}
+
+// ResourcePrinterFunc is a function which implements ResourcePrinter.
+type ResourcePrinterFunc func(obj *Printable, w io.Writer) error
+
+// PrintObj implements ResourcePrinter.
+func (fn ResourcePrinterFunc) PrintObj(obj *Printable, w io.Writer) error {
+	return fn(obj, w)
+}
+
+// OutputFormats are the formats supported by the -o/--output flag.
+var OutputFormats = []string{"table", "wide", "json", "yaml", "name", "csv", "tsv",
+	"go-template=...", "go-template-file=...", "jsonpath=...", "custom-columns=..."}
+
+// AddOutputFlag adds the -o/--output and --template flags.
+func AddOutputFlag(cmd *cobra.Command, usage string) {
+	if usage == "" {
+		usage = "Output format. One of: " + strings.Join(OutputFormats, "|") + "."
+	}
+	cmd.Flags().StringP("output", "o", "", usage)
+	cmd.Flags().String("template", "", "Template string or path to template file to use when -o=go-template, -o=go-template-file or -o=jsonpath. The template format is golang templates [http://golang.org/pkg/text/template/#pkg-overview].")
+}
+
+// PrinterForCommand returns the printer of the format given by -o/--output,
+// the table printer is used if it is empty.
+func PrinterForCommand(cmd *cobra.Command) (ResourcePrinter, error) {
+	return NewPrinter(OutputFormat(cmd), TableOptionsForCommand(cmd))
+}
+
+// PrinterFor returns the printer of the output format, the template formats
+// take their argument after =, such as jsonpath={.username}.
+func PrinterFor(format string) (ResourcePrinter, error) {
+	return NewPrinter(format, TableOptions{})
+}
+
+// NewPrinter returns the printer of the output format, the options apply to
+// the table, wide, name, csv and tsv formats.
+func NewPrinter(format string, options TableOptions) (ResourcePrinter, error) {
+	if printer, ok, err := templatePrinterFor(format); ok {
+		if err != nil {
+			return nil, err
+		}
+		if custom, ok := printer.(*CustomColumnsPrinter); ok {
+			custom.NoHeaders = options.NoHeaders
+		}
+		return printer, nil
+	}
+
+	var printer ResourcePrinter
+	switch format {
+	case "", "table":
+		printer = &TablePrinter{NoHeaders: options.NoHeaders, Width: options.Width}
+	case "wide":
+		printer = &TablePrinter{Wide: true, NoHeaders: options.NoHeaders, Width: options.Width}
+	case "json":
+		return ResourcePrinterFunc(printJSON), nil
+	case "yaml":
+		return ResourcePrinterFunc(printYAML), nil
+	case "name":
+		printer = ResourcePrinterFunc(printName)
+	case "csv":
+		printer = &DelimitedPrinter{Comma: ',', NoHeaders: options.NoHeaders}
+	case "tsv":
+		printer = &DelimitedPrinter{Comma: '\t', NoHeaders: options.NoHeaders}
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, must be one of: %s", format, strings.Join(OutputFormats, "|"))
+	}
+
+	return ResourcePrinterFunc(func(obj *Printable, w io.Writer) error {
+		obj, err := options.Apply(obj)
+		if err != nil {
+			return err
+		}
+		return printer.PrintObj(obj, w)
+	}), nil
+}
+
+// TablePrinter prints the rows as a table, the wide columns are skipped
+// unless Wide is true. The cells are truncated with an ellipsis so that the
+// table fits in the terminal.
+type TablePrinter struct {
+	Wide      bool
+	NoHeaders bool
+	// Width is the width the table must fit in, the width of the terminal
+	// is used if it is 0, and the table is not truncated if w is not one.
+	Width int
+}
+
+// PrintObj implements ResourcePrinter.
+func (p *TablePrinter) PrintObj(obj *Printable, w io.Writer) error {
+	columns := visibleColumns(obj.Columns, p.Wide)
+	header := make([]string, 0, len(columns))
+	for _, i := range columns {
+		header = append(header, obj.Columns[i].Name)
+	}
+	rows := make([][]string, 0, len(obj.Rows))
+	for _, row := range obj.Rows {
+		cells := make([]string, 0, len(columns))
+		for _, i := range columns {
+			cells = append(cells, cellAt(row, i))
+		}
+		rows = append(rows, cells)
+	}
+
+	width := p.Width
+	if width == 0 {
+		width = terminalWidth(w)
+	}
+	widths := fitColumns(header, rows, width)
+
+	table := tablewriter.NewWriter(w)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	// the cells are truncated below instead of being wrapped
+	table.SetAutoWrapText(false)
+	if !p.NoHeaders {
+		for i := range header {
+			header[i] = truncate(header[i], widths[i])
+		}
+		table.SetHeader(header)
+	}
+
+	for r, cells := range rows {
+		for i, cell := range cells {
+			cell = truncate(cell, widths[i])
+			if color := obj.Rows[r].Color; color != nil {
+				cell = color(cell)
+			} else if color := obj.Columns[columns[i]].Color; color != nil {
+				cell = color(cell)
+			}
+			cells[i] = cell
+		}
+		table.Append(cells)
+	}
+	table.Render()
+	return nil
+}
+
+// DelimitedPrinter prints all the columns separated by Comma, with a header
+// line unless NoHeaders is true.
+type DelimitedPrinter struct {
+	Comma     rune
+	NoHeaders bool
+}
+
+// PrintObj implements ResourcePrinter.
+func (p *DelimitedPrinter) PrintObj(obj *Printable, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = p.Comma
+
+	if !p.NoHeaders {
+		header := make([]string, 0, len(obj.Columns))
+		for _, c := range obj.Columns {
+			header = append(header, c.Name)
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range obj.Rows {
+		cells := make([]string, 0, len(obj.Columns))
+		for i := range obj.Columns {
+			cells = append(cells, cellAt(row, i))
+		}
+		if err := writer.Write(cells); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func printJSON(obj *Printable, w io.Writer) error {
+	data, err := json.MarshalIndent(obj.Object, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func printYAML(obj *Printable, w io.Writer) error {
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func printName(obj *Printable, w io.Writer) error {
+	for _, row := range obj.Rows {
+		name := row.Name
+		if obj.Kind != "" {
+			name = obj.Kind + "/" + name
+		}
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visibleColumns returns the indexes of the columns printed in the table.
+func visibleColumns(columns []Column, wide bool) []int {
+	indexes := make([]int, 0, len(columns))
+	for i, c := range columns {
+		if !c.Wide || wide {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func cellAt(row Row, i int) string {
+	if i < len(row.Cells) {
+		return row.Cells[i]
+	}
+	return ""
+}
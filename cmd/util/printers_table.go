@@ -0,0 +1,197 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	cmdterm "cmdctl/cmd/term"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ellipsis ends the truncated cells.
+	ellipsis = "…"
+	// minColumnWidth is the width a column is never truncated below.
+	minColumnWidth = 6
+)
+
+// TableOptions are the options of the tabular printers given by the flags
+// added by AddTableFlags.
+type TableOptions struct {
+	// Columns are the names of the columns to print, in order, all the
+	// visible columns are printed if empty.
+	Columns []string
+	// SortBy is the name of the column to sort the rows by.
+	SortBy    string
+	Reverse   bool
+	NoHeaders bool
+	// Width is the width the tables must fit in, see TablePrinter.
+	Width int
+}
+
+// AddTableFlags adds the --columns and --no-headers flags, and --sort-by if
+// sortBy is true, so that commands with their own --sort-by keep it.
+func AddTableFlags(cmd *cobra.Command, sortBy bool) {
+	cmd.Flags().StringSlice("columns", []string{}, "The columns to print, in order, such as --columns=username,email. The column names are case insensitive.")
+	cmd.Flags().Bool("no-headers", false, "Don't print the headers of the table, csv and tsv output.")
+	if sortBy {
+		cmd.Flags().String("sort-by", "", "The column to sort the rows by, such as --sort-by=username.")
+	}
+}
+
+// TableOptionsForCommand returns the options given by the flags added by
+// AddTableFlags, the flags which are not defined are ignored.
+func TableOptionsForCommand(cmd *cobra.Command) TableOptions {
+	var options TableOptions
+	if cmd.Flags().Lookup("columns") != nil {
+		options.Columns = GetFlagStringSlice(cmd, "columns")
+	}
+	if cmd.Flags().Lookup("no-headers") != nil {
+		options.NoHeaders = GetFlagBool(cmd, "no-headers")
+	}
+	if cmd.Flags().Lookup("sort-by") != nil {
+		options.SortBy = GetFlagString(cmd, "sort-by")
+	}
+	return options
+}
+
+// Apply returns the object with the rows sorted and the columns selected, the
+// selected columns are printed even if they are wide.
+func (o TableOptions) Apply(obj *Printable) (*Printable, error) {
+	if len(o.Columns) == 0 && o.SortBy == "" {
+		return obj, nil
+	}
+
+	result := *obj
+	if o.SortBy != "" {
+		i, err := columnIndex(obj.Columns, o.SortBy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --sort-by: %v", err)
+		}
+		result.Rows = append([]Row{}, obj.Rows...)
+		sort.SliceStable(result.Rows, func(a, b int) bool {
+			if o.Reverse {
+				a, b = b, a
+			}
+			return lessCell(cellAt(result.Rows[a], i), cellAt(result.Rows[b], i))
+		})
+	}
+
+	if len(o.Columns) > 0 {
+		indexes := make([]int, 0, len(o.Columns))
+		columns := make([]Column, 0, len(o.Columns))
+		for _, name := range o.Columns {
+			i, err := columnIndex(obj.Columns, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --columns: %v", err)
+			}
+			column := obj.Columns[i]
+			column.Wide = false
+			columns = append(columns, column)
+			indexes = append(indexes, i)
+		}
+
+		rows := make([]Row, 0, len(result.Rows))
+		for _, row := range result.Rows {
+			cells := make([]string, 0, len(indexes))
+			for _, i := range indexes {
+				cells = append(cells, cellAt(row, i))
+			}
+			rows = append(rows, Row{Name: row.Name, Cells: cells, Color: row.Color})
+		}
+		result.Columns = columns
+		result.Rows = rows
+	}
+	return &result, nil
+}
+
+// columnIndex returns the index of the column, the name is case insensitive.
+func columnIndex(columns []Column, name string) (int, error) {
+	names := make([]string, 0, len(columns))
+	for i, c := range columns {
+		if strings.EqualFold(c.Name, name) {
+			return i, nil
+		}
+		names = append(names, strings.ToLower(c.Name))
+	}
+	return -1, fmt.Errorf("unknown column %q, must be one of: %s", name, strings.Join(names, ", "))
+}
+
+// lessCell compares the cells as numbers if both are numbers, or else as strings.
+func lessCell(a, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return a < b
+}
+
+// ansiEscape matches the color escape sequences, which take no room on the terminal.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// displayWidth returns the number of terminal cells of s, the East-Asian
+// wide characters take two cells.
+func displayWidth(s string) int {
+	return runewidth.StringWidth(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// terminalWidth returns the width of the terminal of w, or 0 if w is not a
+// terminal.
+func terminalWidth(w io.Writer) int {
+	size := cmdterm.TTY{Out: w}.GetSize()
+	if size == nil {
+		return 0
+	}
+	return int(size.Width)
+}
+
+// fitColumns returns the width of every column so that the table fits in
+// width, the widest columns are narrowed first. The natural widths are
+// returned if width is 0.
+func fitColumns(header []string, rows [][]string, width int) []int {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = displayWidth(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := displayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	if width <= 0 {
+		return widths
+	}
+
+	// every column takes its width plus "| " and " ", the table ends with "|"
+	available := width - 3*len(widths) - 1
+	for {
+		total, widest := 0, -1
+		for i, w := range widths {
+			total += w
+			if widest < 0 || w > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= available || widest < 0 || widths[widest] <= minColumnWidth {
+			return widths
+		}
+		widths[widest]--
+	}
+}
+
+// truncate cuts s to width terminal cells, ending with an ellipsis.
+func truncate(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	return runewidth.Truncate(ansiEscape.ReplaceAllString(s, ""), width, ellipsis)
+}
@@ -0,0 +1,253 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"cmdctl/pkg/jsonpath"
+
+	"github.com/spf13/cobra"
+)
+
+// TemplateFormats are the output formats which take an argument, such as
+// -o jsonpath='{.username}', the argument may be given by --template instead.
+var TemplateFormats = []string{"go-template", "go-template-file", "jsonpath", "custom-columns"}
+
+// templateAliases are the short names of the template formats.
+var templateAliases = map[string]string{
+	"template":     "go-template",
+	"templatefile": "go-template-file",
+}
+
+// OutputFormat returns the format given by -o/--output, the --template flag
+// is appended to the template formats given without an argument.
+func OutputFormat(cmd *cobra.Command) string {
+	format := GetFlagString(cmd, "output")
+	if strings.Contains(format, "=") {
+		return format
+	}
+	if alias, ok := templateAliases[format]; ok {
+		format = alias
+	}
+
+	if flag := cmd.Flags().Lookup("template"); flag != nil && flag.Value.String() != "" {
+		for _, f := range TemplateFormats {
+			if format == f {
+				return format + "=" + flag.Value.String()
+			}
+		}
+	}
+	return format
+}
+
+// templatePrinterFor returns the printer of the format=argument output
+// formats, ok is false if format is not one of them.
+func templatePrinterFor(format string) (printer ResourcePrinter, ok bool, err error) {
+	name, arg := format, ""
+	if i := strings.Index(format, "="); i >= 0 {
+		name, arg = format[:i], format[i+1:]
+	}
+	if alias, found := templateAliases[name]; found {
+		name = alias
+	}
+
+	switch name {
+	case "go-template":
+		printer, err = NewGoTemplatePrinter(arg)
+	case "go-template-file":
+		if arg == "" {
+			return nil, true, fmt.Errorf("-o go-template-file requires a file name, such as -o go-template-file=users.tmpl")
+		}
+		var data []byte
+		if data, err = ioutil.ReadFile(arg); err != nil {
+			return nil, true, fmt.Errorf("error reading template file: %v", err)
+		}
+		printer, err = NewGoTemplatePrinter(string(data))
+	case "jsonpath":
+		printer, err = NewJSONPathPrinter(arg)
+	case "custom-columns":
+		printer, err = NewCustomColumnsPrinter(arg)
+	default:
+		return nil, false, nil
+	}
+	return printer, true, err
+}
+
+// genericObject converts the object to the maps and slices of encoding/json,
+// so that the templates refer to the fields by their json names.
+func genericObject(obj *Printable) (interface{}, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// GoTemplatePrinter prints the object with a go template.
+type GoTemplatePrinter struct {
+	text     string
+	template *template.Template
+}
+
+// NewGoTemplatePrinter parses the template, a missing field is an error
+// instead of printing "<no value>".
+func NewGoTemplatePrinter(text string) (*GoTemplatePrinter, error) {
+	if text == "" {
+		return nil, fmt.Errorf("template format specified but no template given")
+	}
+
+	t, err := template.New("output").
+		Funcs(template.FuncMap{"json": templateJSON}).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", text, err)
+	}
+	return &GoTemplatePrinter{text: text, template: t}, nil
+}
+
+func templateJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// PrintObj implements ResourcePrinter, nothing is printed if the template
+// fails. The error of text/template has the field path which failed, such as
+// `executing "output" at <.items.foo>: map has no entry for key "foo"`.
+func (p *GoTemplatePrinter) PrintObj(obj *Printable, w io.Writer) error {
+	data, err := genericObject(obj)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := p.template.Execute(&buf, data); err != nil {
+		return fmt.Errorf("error executing template %q: %v", p.text, err)
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// JSONPathPrinter prints the object with a jsonpath template.
+type JSONPathPrinter struct {
+	text string
+	path *jsonpath.JSONPath
+}
+
+// NewJSONPathPrinter parses the jsonpath template.
+func NewJSONPathPrinter(text string) (*JSONPathPrinter, error) {
+	if text == "" {
+		return nil, fmt.Errorf("jsonpath format specified but no template given")
+	}
+
+	path, err := jsonpath.Parse("output", text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing jsonpath %s: %v", text, err)
+	}
+	return &JSONPathPrinter{text: text, path: path}, nil
+}
+
+// PrintObj implements ResourcePrinter, the error has the field path which
+// failed, such as `.items[0].foo is not found`.
+func (p *JSONPathPrinter) PrintObj(obj *Printable, w io.Writer) error {
+	data, err := genericObject(obj)
+	if err != nil {
+		return err
+	}
+
+	if err := p.path.Execute(w, data); err != nil {
+		return fmt.Errorf("error executing jsonpath %q: %v", p.text, err)
+	}
+	return nil
+}
+
+// CustomColumn is a column of -o custom-columns, such as NAME:.username.
+type CustomColumn struct {
+	Header string
+	Path   *jsonpath.Path
+}
+
+// CustomColumnsPrinter prints a column per field path, a row per element if
+// the object is an array.
+type CustomColumnsPrinter struct {
+	Columns   []CustomColumn
+	NoHeaders bool
+}
+
+// NewCustomColumnsPrinter parses the comma separated HEADER:PATH specs.
+func NewCustomColumnsPrinter(spec string) (*CustomColumnsPrinter, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns format specified but no custom columns given, such as -o custom-columns=NAME:.username")
+	}
+
+	p := &CustomColumnsPrinter{}
+	for _, part := range strings.Split(spec, ",") {
+		fields := strings.SplitN(part, ":", 2)
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("unexpected custom-columns spec: %s, expected <header>:<json-path-expr>", part)
+		}
+
+		path, err := jsonpath.ParsePath(strings.TrimSuffix(strings.TrimPrefix(fields[1], "{"), "}"))
+		if err != nil {
+			return nil, err
+		}
+		p.Columns = append(p.Columns, CustomColumn{Header: fields[0], Path: path})
+	}
+	return p, nil
+}
+
+// PrintObj implements ResourcePrinter, <none> is printed for the fields
+// which do not exist.
+func (p *CustomColumnsPrinter) PrintObj(obj *Printable, w io.Writer) error {
+	data, err := genericObject(obj)
+	if err != nil {
+		return err
+	}
+
+	items, ok := data.([]interface{})
+	if !ok {
+		items = []interface{}{data}
+	}
+
+	tw := tabwriter.NewWriter(w, 5, 8, 3, ' ', 0)
+	if !p.NoHeaders {
+		headers := make([]string, 0, len(p.Columns))
+		for _, c := range p.Columns {
+			headers = append(headers, c.Header)
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	}
+
+	for _, item := range items {
+		cells := make([]string, 0, len(p.Columns))
+		for _, c := range p.Columns {
+			cells = append(cells, customColumnValue(c.Path, item))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func customColumnValue(path *jsonpath.Path, item interface{}) string {
+	values, err := path.Evaluate(item)
+	if err != nil || len(values) == 0 {
+		return "<none>"
+	}
+
+	cells := make([]string, 0, len(values))
+	for _, v := range values {
+		cells = append(cells, jsonpath.Format(v))
+	}
+	return strings.Join(cells, ",")
+}
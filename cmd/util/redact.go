@@ -0,0 +1,145 @@
+package util
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// SecretArgsAnnotation is the command annotation listing the indexes of
+	// the positional arguments which are secrets, separated by commas.
+	SecretArgsAnnotation = "cmdctl/secret-args"
+
+	redacted = "******"
+)
+
+// secretFlagRegexp matches the names of the flags whose values are secrets.
+var secretFlagRegexp = regexp.MustCompile(`(?i)(passwd|password|secret|token)`)
+
+// SetSecretArgs marks the positional arguments at the indexes as secrets, so they
+// are redacted from the recorded command line.
+func SetSecretArgs(cmd *cobra.Command, indexes ...int) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+
+	s := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		s = append(s, strconv.Itoa(i))
+	}
+	cmd.Annotations[SecretArgsAnnotation] = strings.Join(s, ",")
+	return cmd
+}
+
+func secretArgs(cmd *cobra.Command) map[int]bool {
+	secrets := make(map[int]bool)
+	for _, s := range strings.Split(cmd.Annotations[SecretArgsAnnotation], ",") {
+		if i, err := strconv.Atoi(s); err == nil {
+			secrets[i] = true
+		}
+	}
+	return secrets
+}
+
+func isSecretFlag(flag *pflag.Flag) bool {
+	return flag != nil && secretFlagRegexp.MatchString(flag.Name)
+}
+
+// takesValue reports whether the flag consumes a value, bool flags do not.
+func takesValue(flag *pflag.Flag) bool {
+	return flag != nil && flag.NoOptDefVal == ""
+}
+
+// mayPrecedeSecret reports whether the flag is a bool flag named like a
+// secret, such as --password of update which used to take the password. The
+// positional argument following it is redacted, since it is the password
+// whenever the old form is used, which is rejected only after the command
+// line is recorded. The -stdin flags read the secret from stdin instead.
+func mayPrecedeSecret(flag *pflag.Flag) bool {
+	return flag != nil && !takesValue(flag) && isSecretFlag(flag) && !strings.HasSuffix(flag.Name, "-stdin")
+}
+
+// RedactArgs replaces the values of secret flags and secret positional
+// arguments of cmd in args, which are the arguments without the program name.
+func RedactArgs(cmd *cobra.Command, args []string) []string {
+	flags := cmd.Flags()
+	secrets := secretArgs(cmd)
+	// the leading positional arguments are the names of the (sub)commands
+	commandWords := len(strings.Fields(cmd.CommandPath())) - 1
+
+	result := make([]string, len(args))
+	copy(result, args)
+
+	positional := 0
+	for i := 0; i < len(result); i++ {
+		arg := result[i]
+		switch {
+		case arg == "--":
+			for j := i + 1; j < len(result); j++ {
+				if secrets[positional-commandWords] {
+					result[j] = redacted
+				}
+				positional++
+			}
+			return result
+		case strings.HasPrefix(arg, "--"):
+			name := arg[2:]
+			eq := strings.Index(name, "=")
+			hasValue := eq >= 0
+			if hasValue {
+				name = name[:eq]
+			}
+			flag := flags.Lookup(name)
+			if hasValue {
+				if isSecretFlag(flag) {
+					result[i] = "--" + name + "=" + redacted
+				}
+			} else if takesValue(flag) && i+1 < len(result) {
+				i++
+				if isSecretFlag(flag) {
+					result[i] = redacted
+				}
+			} else if mayPrecedeSecret(flag) && i+1 < len(result) && !strings.HasPrefix(result[i+1], "-") {
+				// still a positional argument, only the value is hidden
+				i++
+				result[i] = redacted
+				positional++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// shorthands can be combined, such as -yp VALUE or -pVALUE
+			for j := 1; j < len(arg); j++ {
+				flag := flags.ShorthandLookup(arg[j : j+1])
+				if !takesValue(flag) {
+					if j+1 == len(arg) && mayPrecedeSecret(flag) && i+1 < len(result) && !strings.HasPrefix(result[i+1], "-") {
+						i++
+						result[i] = redacted
+						positional++
+					}
+					continue
+				}
+				if j+1 < len(arg) {
+					if isSecretFlag(flag) {
+						result[i] = arg[:j+1] + redacted
+					}
+				} else if i+1 < len(result) {
+					i++
+					if isSecretFlag(flag) {
+						result[i] = redacted
+					}
+				}
+				break
+			}
+		default:
+			if secrets[positional-commandWords] {
+				result[i] = redacted
+			}
+			positional++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,139 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRedactArgs(t *testing.T) {
+	root := &cobra.Command{Use: "cmdctl"}
+	user := &cobra.Command{Use: "user"}
+	create := &cobra.Command{Use: "create USERNAME PASSWORD"}
+	create.Flags().StringP("password", "p", "", "")
+	create.Flags().String("db-secret", "", "")
+	create.Flags().StringP("output", "o", "", "")
+	create.Flags().BoolP("yes", "y", false, "")
+	create.Flags().Bool("password-stdin", false, "")
+	SetSecretArgs(create, 1)
+	root.AddCommand(user)
+	user.AddCommand(create)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "secret positional argument",
+			args: []string{"user", "create", "alice", "s3cret"},
+			want: []string{"user", "create", "alice", redacted},
+		},
+		{
+			name: "flag with a separate value",
+			args: []string{"user", "create", "--password", "s3cret", "-o", "json"},
+			want: []string{"user", "create", "--password", redacted, "-o", "json"},
+		},
+		{
+			name: "flag with an inline value",
+			args: []string{"user", "create", "--password=s3=cret", "--output=json"},
+			want: []string{"user", "create", "--password=" + redacted, "--output=json"},
+		},
+		{
+			name: "flag named like a secret",
+			args: []string{"user", "create", "--db-secret", "s3cret", "alice"},
+			want: []string{"user", "create", "--db-secret", redacted, "alice"},
+		},
+		{
+			name: "bool flag named like a secret takes no value",
+			args: []string{"user", "create", "--password-stdin", "alice", "s3cret"},
+			want: []string{"user", "create", "--password-stdin", "alice", redacted},
+		},
+		{
+			name: "shorthand with a separate value",
+			args: []string{"user", "create", "-p", "s3cret", "alice"},
+			want: []string{"user", "create", "-p", redacted, "alice"},
+		},
+		{
+			name: "shorthand with an attached value",
+			args: []string{"user", "create", "-ps3cret"},
+			want: []string{"user", "create", "-p" + redacted},
+		},
+		{
+			name: "combined shorthands",
+			args: []string{"user", "create", "-yp", "s3cret", "-yps3cret"},
+			want: []string{"user", "create", "-yp", redacted, "-yp" + redacted},
+		},
+		{
+			name: "flag values are not positional arguments",
+			args: []string{"user", "create", "-o", "json", "alice", "s3cret"},
+			want: []string{"user", "create", "-o", "json", "alice", redacted},
+		},
+		{
+			name: "arguments after --",
+			args: []string{"user", "create", "--", "alice", "-s3cret"},
+			want: []string{"user", "create", "--", "alice", redacted},
+		},
+		{
+			name: "flag without its value",
+			args: []string{"user", "create", "--password"},
+			want: []string{"user", "create", "--password"},
+		},
+		{
+			name: "unknown flag",
+			args: []string{"user", "create", "--unknown=s3cret", "alice", "s3cret"},
+			want: []string{"user", "create", "--unknown=s3cret", "alice", redacted},
+		},
+	}
+
+	for _, tt := range tests {
+		args := append([]string{}, tt.args...)
+		got := RedactArgs(create, args)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: RedactArgs(%v) = %v, want %v", tt.name, tt.args, got, tt.want)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: RedactArgs modified its args to %v", tt.name, args)
+		}
+	}
+}
+
+// update used to take the password as the value of --password, which is now
+// a bool flag, so the old form passes the password as a positional argument.
+func TestRedactArgsOldPasswordFlag(t *testing.T) {
+	root := &cobra.Command{Use: "cmdctl"}
+	update := &cobra.Command{Use: "update user NAME"}
+	update.Flags().StringP("email", "e", "", "")
+	update.Flags().BoolP("password", "p", false, "")
+	update.Flags().Bool("password-stdin", false, "")
+	root.AddCommand(update)
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"update", "alice", "--password", "s3cret"},
+			want: []string{"update", "alice", "--password", redacted},
+		},
+		{
+			args: []string{"update", "user", "alice", "-p", "s3cret", "-e", "alice@example.com"},
+			want: []string{"update", "user", "alice", "-p", redacted, "-e", "alice@example.com"},
+		},
+		{
+			args: []string{"update", "user", "alice", "--password", "--email", "alice@example.com"},
+			want: []string{"update", "user", "alice", "--password", "--email", "alice@example.com"},
+		},
+		{
+			args: []string{"update", "user", "alice", "--password-stdin"},
+			want: []string{"update", "user", "alice", "--password-stdin"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := RedactArgs(update, tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RedactArgs(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
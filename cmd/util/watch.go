@@ -0,0 +1,253 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"cmdctl/pkg/interrupt"
+	"cmdctl/pkg/term"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultWatchInterval is the default of --interval.
+	DefaultWatchInterval = 2 * time.Second
+
+	clearScreen = "\x1b[H\x1b[2J"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+)
+
+// The types of the watch events.
+const (
+	WatchAdded    = "ADDED"
+	WatchModified = "MODIFIED"
+	WatchDeleted  = "DELETED"
+)
+
+// WatchEvent is a change between two polls, printed as a json line when the
+// output is not a terminal.
+type WatchEvent struct {
+	Type   string      `json:"type"`
+	Kind   string      `json:"kind,omitempty"`
+	Name   string      `json:"name"`
+	Object interface{} `json:"object,omitempty"`
+}
+
+// AddWatchFlags adds the -w/--watch and --interval flags.
+func AddWatchFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("watch", "w", false, "After printing, keep polling and redraw the output, or print the changes as json lines if the output is not a terminal.")
+	cmd.Flags().Duration("interval", DefaultWatchInterval, "The interval between two polls in --watch mode.")
+}
+
+// Watcher polls the output of a command until it is interrupted.
+type Watcher struct {
+	Out      io.Writer
+	Interval time.Duration
+	// Printer draws the output on a terminal.
+	Printer ResourcePrinter
+	// Title is printed above the output on a terminal, such as "cmdctl list".
+	Title string
+
+	previous map[string]watchedRow
+	// removed are the rows deleted by the last poll, drawn once more in red.
+	removed []Row
+}
+
+type watchedRow struct {
+	row    Row
+	object interface{}
+}
+
+// NewWatcher returns a watcher for the flags added by AddWatchFlags, the
+// output is drawn in the format, with the tables fitting in the terminal.
+func NewWatcher(cmd *cobra.Command, out io.Writer, format string, options TableOptions) (*Watcher, error) {
+	interval := GetFlagDuration(cmd, "interval")
+	if interval <= 0 {
+		return nil, fmt.Errorf("--interval must be greater than 0")
+	}
+
+	// the output is rendered in a buffer before it is drawn
+	options.Width = terminalWidth(out)
+	printer, err := NewPrinter(format, options)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{Out: out, Interval: interval, Printer: printer, Title: cmd.CommandPath()}, nil
+}
+
+// Run calls poll every interval, it returns nil when interrupted by SIGINT
+// or SIGTERM so that cmdctl exits cleanly.
+func (w *Watcher) Run(poll func() (*Printable, error)) error {
+	fd, isTerminal := term.GetFdInfo(w.Out)
+
+	stop := make(chan struct{})
+	var notify []func()
+	if isTerminal {
+		fmt.Fprint(w.Out, hideCursor)
+		notify = append(notify, func() { fmt.Fprint(w.Out, showCursor) })
+	}
+	handler := interrupt.New(func(os.Signal) { close(stop) }, notify...)
+
+	return handler.Run(func() error {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			obj, err := poll()
+			if err != nil {
+				return err
+			}
+			if isTerminal {
+				err = w.redraw(obj, fd)
+			} else {
+				err = w.printChanges(obj)
+			}
+			if err != nil {
+				return err
+			}
+
+			select {
+			case <-stop:
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// redraw draws the output in place, the rows added since the previous poll
+// are green and the removed ones are red.
+func (w *Watcher) redraw(obj *Printable, fd uintptr) error {
+	first := w.previous == nil
+	added, modified, _ := w.diff(obj)
+	if first {
+		// nothing is highlighted on the first poll
+		added = nil
+	}
+
+	rows := make([]Row, 0, len(obj.Rows)+len(w.removed))
+	for _, row := range obj.Rows {
+		switch {
+		case added[row.Name]:
+			row.Color = colorFunc(color.FgGreen)
+		case modified[row.Name]:
+			row.Color = colorFunc(color.FgYellow)
+		}
+		rows = append(rows, row)
+	}
+	for _, row := range w.removed {
+		row.Color = colorFunc(color.FgRed)
+		rows = append(rows, row)
+	}
+
+	drawn := *obj
+	drawn.Rows = rows
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Every %s: %s\t%s\n\n", w.Interval, w.Title, time.Now().Format(time.RFC1123))
+	if err := w.Printer.PrintObj(&drawn, &buf); err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if winsize, err := term.GetWinsize(fd); err == nil && winsize.Height > 1 && len(lines) > int(winsize.Height)-1 {
+		lines = lines[:winsize.Height-1]
+	}
+	_, err := fmt.Fprint(w.Out, clearScreen+strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// printChanges prints the changes since the previous poll as json lines,
+// every row is ADDED by the first poll.
+func (w *Watcher) printChanges(obj *Printable) error {
+	events := make([]WatchEvent, 0)
+	added, modified, deleted := w.diff(obj)
+	for _, row := range obj.Rows {
+		switch {
+		case added[row.Name]:
+			events = append(events, WatchEvent{Type: WatchAdded, Kind: obj.Kind, Name: row.Name, Object: w.previous[row.Name].object})
+		case modified[row.Name]:
+			events = append(events, WatchEvent{Type: WatchModified, Kind: obj.Kind, Name: row.Name, Object: w.previous[row.Name].object})
+		}
+	}
+	for _, d := range deleted {
+		events = append(events, WatchEvent{Type: WatchDeleted, Kind: obj.Kind, Name: d.row.Name, Object: d.object})
+	}
+
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w.Out, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diff compares the rows with the previous poll by their names, and keeps
+// them for the next poll.
+func (w *Watcher) diff(obj *Printable) (added, modified map[string]bool, deleted []watchedRow) {
+	objects := rowObjects(obj)
+	current := make(map[string]watchedRow, len(obj.Rows))
+	added = make(map[string]bool)
+	modified = make(map[string]bool)
+	for i, row := range obj.Rows {
+		current[row.Name] = watchedRow{row: row, object: objects[i]}
+
+		previous, ok := w.previous[row.Name]
+		switch {
+		case !ok:
+			added[row.Name] = true
+		case strings.Join(previous.row.Cells, "\x00") != strings.Join(row.Cells, "\x00"):
+			modified[row.Name] = true
+		}
+	}
+
+	for _, row := range w.previous {
+		if _, ok := current[row.row.Name]; !ok {
+			deleted = append(deleted, row)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].row.Name < deleted[j].row.Name })
+
+	w.removed = w.removed[:0]
+	for _, d := range deleted {
+		w.removed = append(w.removed, d.row)
+	}
+	w.previous = current
+	return added, modified, deleted
+}
+
+// rowObjects returns the object of every row, the elements of the object if
+// it is an array of a row each, or else the whole object.
+func rowObjects(obj *Printable) []interface{} {
+	objects := make([]interface{}, len(obj.Rows))
+	generic, err := genericObject(obj)
+	if err != nil {
+		return objects
+	}
+
+	if items, ok := generic.([]interface{}); ok && len(items) == len(obj.Rows) {
+		return items
+	}
+	for i := range objects {
+		objects[i] = generic
+	}
+	return objects
+}
+
+func colorFunc(attribute color.Attribute) func(string) string {
+	sprint := color.New(attribute).SprintFunc()
+	return func(value string) string { return sprint(value) }
+}
@@ -4,29 +4,41 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"os"
 
 	"cmdctl/cmd/templates"
 	cmdutil "cmdctl/cmd/util"
 	"cmdctl/pkg/i18n"
 
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
 var (
 	validateExample = templates.Examples(i18n.T(`
 		# Validate the basic environment for cmdctl to run
-		cmdctl validate`))
+		cmdctl validate
+
+		# Validate the environment and print the results as json
+		cmdctl validate -o json
+
+		# Print the results sorted by result, the failed checks first
+		cmdctl validate --sort-by result --columns result,validateitem
+
+		# Print the failed checks only
+		cmdctl validate -o go-template='{{range .}}{{if eq .status "FAIL"}}{{.itemName}}: {{.message}}{{"\n"}}{{end}}{{end}}'`))
 )
 
 type ValidateInfo struct {
-	ItemName string
-	Status   string
-	Message  string
+	ItemName string `json:"itemName"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
 }
 
+const (
+	validatePass = "PASS"
+	validateFail = "FAIL"
+)
+
 func NewCmdValidate(f cmdutil.Factory, out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "validate",
@@ -39,36 +51,54 @@ func NewCmdValidate(f cmdutil.Factory, out io.Writer) *cobra.Command {
 		},
 		Aliases: []string{"va", ""},
 	}
+
+	cmdutil.AddOutputFlag(cmd, "")
+	cmdutil.AddTableFlags(cmd, true)
 	return cmd
 }
 
 func RunValidate(f cmdutil.Factory, out io.Writer, cmd *cobra.Command) error {
-	data := [][]string{}
-	FAIL := color.RedString("FAIL")
-	PASS := color.GreenString("PASS")
-	validateInfo := ValidateInfo{}
+	printer, err := cmdutil.PrinterForCommand(cmd)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%s", err)
+	}
+
+	data := []*ValidateInfo{}
+	validateInfo := &ValidateInfo{}
 
 	// check if can access db
 	validateInfo.ItemName = "db connection"
-	_, err := net.Dial("tcp", f.FileServer().Server)
+	_, err = net.Dial("tcp", f.FileServer().Server)
 	//defer client.Close()
 	if err != nil {
-		validateInfo.Status = FAIL
+		validateInfo.Status = validateFail
 		validateInfo.Message = fmt.Sprintf("%v", err)
 	} else {
-		validateInfo.Status = PASS
+		validateInfo.Status = validatePass
 		validateInfo.Message = ""
 	}
-	data = append(data, []string{validateInfo.ItemName, validateInfo.Status, validateInfo.Message})
-
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetColWidth(TABLE_WIDTH)
-	table.SetHeader([]string{"ValidateItem", "Result", "Message"})
+	data = append(data, validateInfo)
 
+	rows := make([]cmdutil.Row, 0, len(data))
 	for _, v := range data {
-		table.Append(v)
+		rows = append(rows, cmdutil.Row{Name: v.ItemName, Cells: []string{v.ItemName, v.Status, v.Message}})
+	}
+
+	return printer.PrintObj(&cmdutil.Printable{
+		Kind:   "check",
+		Object: data,
+		Columns: []cmdutil.Column{
+			{Name: "ValidateItem"},
+			{Name: "Result", Color: colorValidateStatus},
+			{Name: "Message"},
+		},
+		Rows: rows,
+	}, out)
+}
+
+func colorValidateStatus(status string) string {
+	if status == validatePass {
+		return color.GreenString(status)
 	}
-	table.Render() // Send output
-	return nil
+	return color.RedString(status)
 }
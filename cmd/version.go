@@ -1,16 +1,14 @@
 package cmd
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"cmdctl/cmd/templates"
 	cmdutil "cmdctl/cmd/util"
 	"cmdctl/pkg/version"
 
-	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 )
 
@@ -29,7 +27,13 @@ type VersionOptions struct {
 var (
 	versionExample = templates.Examples(`
 		# Print the client and server versions for the current context
-		cmdctl version`)
+		cmdctl version
+
+		# Print the versions as a table with the build details
+		cmdctl version -o wide
+
+		# Print the client git tag only
+		cmdctl version -o go-template='{{.clientVersion.gitTag}}'`)
 )
 
 func NewCmdVersion(out io.Writer) *cobra.Command {
@@ -46,7 +50,7 @@ func NewCmdVersion(out io.Writer) *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolP("short", "", false, "Print just the version number.")
-	cmd.Flags().StringP("output", "o", "", "One of 'yaml' or 'json'.")
+	cmdutil.AddOutputFlag(cmd, "Output format. One of: "+strings.Join(cmdutil.OutputFormats, "|")+", the version is printed as a string if empty.")
 	return cmd
 }
 
@@ -74,36 +78,62 @@ func (o *VersionOptions) Run(out io.Writer) error {
 				fmt.Fprintf(out, "Server Version: %s\n", fmt.Sprintf("%#v", *serverVersion))
 			}
 		}
-	case "yaml":
-		marshalled, err := yaml.Marshal(&versionInfo)
+	default:
+		printer, err := cmdutil.PrinterFor(o.output)
 		if err != nil {
-			return err
+			// There is a bug in the program if we hit this case.
+			// However, we follow a policy of never panicking.
+			return fmt.Errorf("VersionOptions were not validated: --output=%q should have been rejected", o.output)
 		}
-		fmt.Fprintln(out, string(marshalled))
-	case "json":
-		marshalled, err := json.MarshalIndent(&versionInfo, "", "  ")
-		if err != nil {
+		if err := printer.PrintObj(versionInfo.printable(), out); err != nil {
 			return err
 		}
-		fmt.Fprintln(out, string(marshalled))
-	default:
-		// There is a bug in the program if we hit this case.
-		// However, we follow a policy of never panicking.
-		return fmt.Errorf("VersionOptions were not validated: --output=%q should have been rejected", o.output)
 	}
 
 	return serverErr
 }
 
+// printable returns the versions in the form of the printers.
+func (v *Version) printable() *cmdutil.Printable {
+	obj := &cmdutil.Printable{
+		Kind:   "version",
+		Object: v,
+		Columns: []cmdutil.Column{
+			{Name: "Component"},
+			{Name: "Version"},
+			{Name: "GitCommit", Wide: true},
+			{Name: "BuildDate", Wide: true},
+			{Name: "GoVersion", Wide: true},
+			{Name: "Platform", Wide: true},
+		},
+	}
+
+	for _, c := range []struct {
+		name string
+		info *version.Info
+	}{{"client", v.ClientVersion}, {"server", v.ServerVersion}} {
+		if c.info == nil {
+			continue
+		}
+		obj.Rows = append(obj.Rows, cmdutil.Row{
+			Name:  c.name,
+			Cells: []string{c.name, c.info.GitTag, c.info.GitCommit, c.info.BuildDate, c.info.GoVersion, c.info.Platform},
+		})
+	}
+	return obj
+}
+
 func (o *VersionOptions) Complete(cmd *cobra.Command) error {
 	o.short = cmdutil.GetFlagBool(cmd, "short")
-	o.output = cmdutil.GetFlagString(cmd, "output")
+	o.output = cmdutil.OutputFormat(cmd)
 	return nil
 }
 
 func (o *VersionOptions) Validate() error {
-	if o.output != "" && o.output != "yaml" && o.output != "json" {
-		return errors.New(`--output must be 'yaml' or 'json'`)
+	if o.output != "" {
+		if _, err := cmdutil.PrinterFor(o.output); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -1,17 +1,27 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"cmdctl/cmd"
 	cmdutil "cmdctl/cmd/util"
+	"cmdctl/model"
 )
 
 func main() {
+	// registered first so it runs after the other hooks, which may still
+	// use the datasources
+	cmdutil.OnExit(func(code int) {
+		if err := model.CloseAll(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	})
+
 	cmd := cmd.NewCommand(cmdutil.NewFactory(), os.Stdin, os.Stdout, os.Stderr)
 	if cmd.Execute() != nil {
-		os.Exit(1)
+		cmdutil.Exit(1)
 	}
 
-	os.Exit(0)
+	cmdutil.Exit(0)
 }
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// AuditModel records an invocation of a mutating command.
+type AuditModel struct {
+	Id         uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"-"`
+	Command    string    `gorm:"column:command;not null;index" json:"command"`
+	Args       string    `gorm:"column:args;type:text" json:"args"`
+	Username   string    `gorm:"column:username;index" json:"username"`
+	Host       string    `gorm:"column:host" json:"host"`
+	StartedAt  time.Time `gorm:"column:startedAt;index" json:"startedAt"`
+	FinishedAt time.Time `gorm:"column:finishedAt" json:"finishedAt"`
+	ExitCode   int       `gorm:"column:exitCode" json:"exitCode"`
+}
+
+func (a *AuditModel) TableName() string {
+	return "tb_cmdctl_audit"
+}
+
+// ListAuditOptions holds the conditions used to list audit records.
+type ListAuditOptions struct {
+	Username string
+	// Since lists the records started after it, zero means no limit.
+	Since time.Time
+	// Command matches the records whose command contains it.
+	Command string
+	Limit   int
+}
+
+// Match reports whether the record matches the conditions, it is used to filter
+// the records which are not stored in the database.
+func (opts *ListAuditOptions) Match(a *AuditModel) bool {
+	if opts.Username != "" && a.Username != opts.Username {
+		return false
+	}
+	if !opts.Since.IsZero() && a.StartedAt.Before(opts.Since) {
+		return false
+	}
+	if opts.Command != "" && !strings.Contains(a.Command, opts.Command) {
+		return false
+	}
+	return true
+}
+
+// CreateAudit saves the audit record with the given connection.
+func CreateAudit(db *gorm.DB, a *AuditModel) error {
+	return db.Create(a).Error
+}
+
+// ListAudits lists the audit records matching opts, the newest first.
+func ListAudits(opts *ListAuditOptions) ([]*AuditModel, error) {
+	db, err := GetSelfDB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Model(&AuditModel{})
+	if opts.Username != "" {
+		query = query.Where("username = ?", opts.Username)
+	}
+	if !opts.Since.IsZero() {
+		query = query.Where(quote(db, "startedAt")+" >= ?", opts.Since)
+	}
+	if opts.Command != "" {
+		query = query.Where("command LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(opts.Command)+"%")
+	}
+	if opts.Limit > 0 {
+		query = query.Limit(opts.Limit)
+	}
+
+	audits := make([]*AuditModel, 0)
+	err = query.Order("id desc").Find(&audits).Error
+	return audits, err
+}
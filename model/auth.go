@@ -0,0 +1,49 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"cmdctl/pkg/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrPasswordMismatch is returned when the password does not match the stored hash.
+	ErrPasswordMismatch = errors.New("username or password is incorrect")
+)
+
+// MaxPasswordBytes is the length of the passwords hashed by bcrypt, the
+// bytes after it would be silently ignored.
+const MaxPasswordBytes = 72
+
+// Encrypt hashes the plain text password with bcrypt, the cost is
+// `password.cost` of the config, which defaults to bcrypt.DefaultCost.
+func Encrypt(source string) (string, error) {
+	if len(source) > MaxPasswordBytes {
+		return "", fmt.Errorf("password is %d bytes long, it must be at most %d bytes", len(source), MaxPasswordBytes)
+	}
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(source), config.Get().Password.Cost)
+	return string(hashedBytes), err
+}
+
+// Compare compares the hashed password with the plain text password.
+func Compare(hashedPassword, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+}
+
+// IsEncrypted reports whether the password is already a bcrypt hash.
+func IsEncrypted(password string) bool {
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
+
+// VerifyPassword checks the plain text password of the user against the stored hash.
+func VerifyPassword(username, password string) error {
+	store, err := selfUserStore()
+	if err != nil {
+		return err
+	}
+	return CheckPassword(store, username, password)
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/secret"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// SelfDataSource is the datasource of the cmdctl tables, the database
+	// of the current context takes precedence over `datasources.self`, which
+	// falls back to the `db` settings if not configured.
+	SelfDataSource = "self"
+	// DockerDataSource shares the connection of SelfDataSource if not configured.
+	DockerDataSource = "docker"
+)
+
+// DataSourceConfig holds the connection and pool settings of a datasource.
+type DataSourceConfig struct {
+	*DBConfig
+	// MaxOpenConns is the max number of open connections, 0 means no limit.
+	MaxOpenConns int
+	// MaxIdleConns is the max number of idle connections kept in the pool,
+	// 0 keeps the database/sql default.
+	MaxIdleConns int
+	// ConnMaxLifetime is the max time a connection may be reused, 0 means forever.
+	ConnMaxLifetime time.Duration
+}
+
+// LoadDataSourceConfig returns the settings of the datasource under
+// `datasources.<name>` in the config.
+func LoadDataSourceConfig(name string) (*DataSourceConfig, error) {
+	if name == SelfDataSource {
+		context, err := config.CurrentContext()
+		if err != nil {
+			return nil, err
+		}
+		if context.Database != nil {
+			return newDataSourceConfig(context.Database)
+		}
+	}
+
+	c := config.Get()
+	d, ok := c.DataSources[name]
+	if !ok {
+		switch name {
+		case SelfDataSource:
+			d = c.DB
+		case DockerDataSource:
+			return LoadDataSourceConfig(SelfDataSource)
+		default:
+			return nil, fmt.Errorf("datasource %q not configured", name)
+		}
+	}
+	return newDataSourceConfig(&d)
+}
+
+// newDataSourceConfig converts a database of the config, the driver
+// defaults to mysql and the password may be a secret reference.
+func newDataSourceConfig(d *config.Database) (*DataSourceConfig, error) {
+	password, err := secret.Resolve(d.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &DataSourceConfig{
+		DBConfig: &DBConfig{
+			Driver:   d.Driver,
+			Username: d.Username,
+			Password: password,
+			Addr:     d.Addr,
+			Name:     d.Name,
+		},
+		MaxOpenConns:    d.MaxOpenConns,
+		MaxIdleConns:    d.MaxIdleConns,
+		ConnMaxLifetime: d.ConnMaxLifetime,
+	}
+	if c.Driver == "" {
+		c.Driver = "mysql"
+	}
+	return c, nil
+}
+
+// resolveDataSource returns the name of the datasource whose connection is
+// used by the name.
+func resolveDataSource(name string) string {
+	if _, ok := config.Get().DataSources[name]; name == DockerDataSource && !ok {
+		return SelfDataSource
+	}
+	return name
+}
+
+var (
+	dataSources    = map[string]*gorm.DB{}
+	dataSourcesMux sync.Mutex
+)
+
+// Get returns the process-wide connection pool of the datasource, which is
+// opened on the first call. ErrMemoryDriver is returned for the datasources
+// of the memory driver, which have no connection. The pools are closed by CloseAll, the callers
+// should not close them.
+func Get(name string) (*gorm.DB, error) {
+	name = resolveDataSource(name)
+
+	dataSourcesMux.Lock()
+	defer dataSourcesMux.Unlock()
+
+	if db, ok := dataSources[name]; ok {
+		return db, nil
+	}
+
+	c, err := LoadDataSourceConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	if c.Driver == MemoryDriver {
+		return nil, fmt.Errorf("datasource %s: %v", name, ErrMemoryDriver)
+	}
+
+	db, err := OpenDB(c.DBConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	db.DB().SetMaxOpenConns(c.MaxOpenConns)
+	if c.MaxIdleConns > 0 {
+		db.DB().SetMaxIdleConns(c.MaxIdleConns)
+	}
+	db.DB().SetConnMaxLifetime(c.ConnMaxLifetime)
+
+	dataSources[name] = db
+	return db, nil
+}
+
+// CloseAll closes the opened datasources, the next Get opens them again.
+func CloseAll() error {
+	dataSourcesMux.Lock()
+	defer dataSourcesMux.Unlock()
+
+	names := make([]string, 0, len(dataSources))
+	for name := range dataSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []string
+	for _, name := range names {
+		if err := dataSources[name].Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+		delete(dataSources, name)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("close datasources failed: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DBConfig holds the settings used to connect to a database.
+type DBConfig struct {
+	Driver   string
+	Username string
+	Password string
+	Addr     string
+	Name     string
+}
+
+// Driver knows how to connect to and create a kind of database.
+type Driver interface {
+	// Dialect returns the dialect name passed to gorm.Open.
+	Dialect() string
+	// DSN returns the data source name used to connect to the database.
+	DSN(c *DBConfig) string
+	// CreateDatabase creates the database if not exists, the database is
+	// dropped first if force is true.
+	CreateDatabase(c *DBConfig, force bool) error
+}
+
+var drivers = map[string]Driver{}
+
+// RegisterDriver makes a database driver available by the name.
+func RegisterDriver(name string, driver Driver) {
+	if _, dup := drivers[name]; dup {
+		panic("model: RegisterDriver called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// GetDriver returns the driver registered with the name.
+func GetDriver(name string) (Driver, error) {
+	driver, ok := drivers[name]
+	if !ok {
+		names := make([]string, 0, len(drivers))
+		for n := range drivers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unsupported db driver %q, must be one of: %s", name, strings.Join(names, ", "))
+	}
+
+	return driver, nil
+}
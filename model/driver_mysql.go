@@ -0,0 +1,49 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+
+	// MySQL driver.
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+)
+
+type mysqlDriver struct{}
+
+func init() {
+	RegisterDriver("mysql", mysqlDriver{})
+}
+
+func (mysqlDriver) Dialect() string {
+	return "mysql"
+}
+
+func (mysqlDriver) DSN(c *DBConfig) string {
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8&parseTime=%t&loc=%s",
+		c.Username,
+		c.Password,
+		c.Addr,
+		c.Name,
+		true,
+		//"Asia/Shanghai"),
+		"Local")
+}
+
+func (mysqlDriver) CreateDatabase(c *DBConfig, force bool) error {
+	// connect to the server without selecting a database
+	dsn := fmt.Sprintf("%s:%s@tcp(%s)/?charset=utf8", c.Username, c.Password, c.Addr)
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if force {
+		if _, err := db.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", c.Name)); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE if not exists `%s` CHARSET utf8 COLLATE utf8_general_ci", c.Name))
+	return err
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	// PostgreSQL driver.
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+)
+
+type postgresDriver struct{}
+
+func init() {
+	RegisterDriver("postgres", postgresDriver{})
+}
+
+func (postgresDriver) Dialect() string {
+	return "postgres"
+}
+
+func (postgresDriver) DSN(c *DBConfig) string {
+	return postgresDSN(c, c.Name)
+}
+
+// postgresDSN builds a key/value connection string, the addr is split into
+// host and port since lib/pq does not accept the `host:port` form.
+func postgresDSN(c *DBConfig, dbname string) string {
+	host, port, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		host, port = c.Addr, "5432"
+	}
+
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, c.Username, quotePostgresValue(c.Password), dbname)
+}
+
+func quotePostgresValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+func (postgresDriver) CreateDatabase(c *DBConfig, force bool) error {
+	// connect to the maintenance database since the target may not exist
+	db, err := sql.Open("postgres", postgresDSN(c, "postgres"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	name := `"` + strings.Replace(c.Name, `"`, `""`, -1) + `"`
+	if force {
+		if _, err := db.Exec("DROP DATABASE IF EXISTS " + name); err != nil {
+			return err
+		}
+	}
+
+	// postgres does not support CREATE DATABASE IF NOT EXISTS
+	var exists int
+	err = db.QueryRow("SELECT 1 FROM pg_database WHERE datname = $1", c.Name).Scan(&exists)
+	switch {
+	case err == nil:
+		return nil
+	case err != sql.ErrNoRows:
+		return err
+	}
+
+	_, err = db.Exec("CREATE DATABASE " + name + " ENCODING 'UTF8'")
+	return err
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+
+	"cmdctl/util"
+
+	// SQLite driver.
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+// sqliteDriver stores the database in the file specified by `db.name`,
+// the username, password and addr are ignored.
+type sqliteDriver struct{}
+
+func init() {
+	RegisterDriver("sqlite3", sqliteDriver{})
+}
+
+func (sqliteDriver) Dialect() string {
+	return "sqlite3"
+}
+
+func (sqliteDriver) DSN(c *DBConfig) string {
+	return c.Name
+}
+
+func (d sqliteDriver) CreateDatabase(c *DBConfig, force bool) error {
+	if force {
+		if err := os.Remove(c.Name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := util.EnsureDirExists(filepath.Dir(c.Name)); err != nil {
+		return err
+	}
+
+	// the database file is created on the first connection
+	db, err := sql.Open("sqlite3", d.DSN(c))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Ping()
+}
@@ -0,0 +1,288 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"cmdctl/pkg/version"
+
+	"github.com/jinzhu/gorm"
+)
+
+// DumpFormat is the version of the dump format written by DumpDatabase.
+const DumpFormat = 1
+
+// dumpModel is a table managed by cmdctl, along with the schema versions
+// which create it and add its later columns, so that the databases which
+// are not migrated to the latest version can be dumped too.
+type dumpModel struct {
+	// new returns an empty model of the table.
+	new func() interface{}
+	// since is the schema version creating the table.
+	since uint
+	// columnsSince are the schema versions adding the columns which were
+	// not created along with the table.
+	columnsSince map[string]uint
+}
+
+// dumpModels are the tables managed by cmdctl, in the order they are restored.
+var dumpModels = []*dumpModel{
+	{new: func() interface{} { return &UserModel{} }, since: 1, columnsSince: map[string]uint{"attributes": 4}},
+	{new: func() interface{} { return &RoleModel{} }, since: 2},
+	{new: func() interface{} { return &RolePermissionModel{} }, since: 2},
+	{new: func() interface{} { return &RoleBindingModel{} }, since: 2},
+	{new: func() interface{} { return &AuditModel{} }, since: 3},
+}
+
+// dumpModelsAt returns the tables which exist at the schema version.
+func dumpModelsAt(version uint) []*dumpModel {
+	models := make([]*dumpModel, 0, len(dumpModels))
+	for _, m := range dumpModels {
+		if m.since <= version {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// DumpHeader describes where a dump comes from.
+type DumpHeader struct {
+	Format        int          `json:"format"`
+	SchemaVersion uint         `json:"schemaVersion"`
+	Version       version.Info `json:"version"`
+	CreatedAt     time.Time    `json:"createdAt"`
+}
+
+// DumpTable holds the rows of a table, a row maps the column names to the
+// values, so the dump does not depend on the database driver.
+type DumpTable struct {
+	Name string                       `json:"name"`
+	Rows []map[string]json.RawMessage `json:"rows"`
+}
+
+// Dump is the portable copy of the cmdctl tables.
+type Dump struct {
+	Header DumpHeader   `json:"header"`
+	Tables []*DumpTable `json:"tables"`
+}
+
+// Table returns the dumped table with the name, or nil.
+func (d *Dump) Table(name string) *DumpTable {
+	for _, t := range d.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func tableName(db *gorm.DB, m interface{}) string {
+	return db.NewScope(m).TableName()
+}
+
+// columns returns the fields of the model stored in the table at the schema
+// version, m is a model of the table.
+func (d *dumpModel) columns(db *gorm.DB, m interface{}, version uint) []*gorm.Field {
+	fields := make([]*gorm.Field, 0)
+	for _, field := range db.NewScope(m).Fields() {
+		if field.IsNormal && !field.IsIgnored && d.columnsSince[field.DBName] <= version {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// columnNames returns the names of the columns of the table at the schema version.
+func (d *dumpModel) columnNames(db *gorm.DB, version uint) []string {
+	fields := d.columns(db, d.new(), version)
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.DBName)
+	}
+	return names
+}
+
+// DumpDatabase reads all the rows of the cmdctl tables which exist at the
+// schema version of the database, including the soft deleted ones.
+func DumpDatabase(db *gorm.DB) (*Dump, error) {
+	schemaVersion, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	dump := &Dump{
+		Header: DumpHeader{
+			Format:        DumpFormat,
+			SchemaVersion: schemaVersion,
+			Version:       version.Get(),
+			CreatedAt:     time.Now(),
+		},
+		Tables: make([]*DumpTable, 0, len(dumpModels)),
+	}
+
+	for _, d := range dumpModelsAt(schemaVersion) {
+		m := d.new()
+		names := d.columnNames(db, schemaVersion)
+		for i := range names {
+			names[i] = db.Dialect().Quote(names[i])
+		}
+		records := reflect.New(reflect.SliceOf(reflect.TypeOf(m)))
+		if err := db.Unscoped().Select(strings.Join(names, ", ")).Order("id").Find(records.Interface()).Error; err != nil {
+			return nil, err
+		}
+
+		table := &DumpTable{Name: tableName(db, m), Rows: make([]map[string]json.RawMessage, 0)}
+		for i := 0; i < records.Elem().Len(); i++ {
+			row := make(map[string]json.RawMessage)
+			for _, field := range d.columns(db, records.Elem().Index(i).Interface(), schemaVersion) {
+				value, err := json.Marshal(field.Field.Interface())
+				if err != nil {
+					return nil, fmt.Errorf("table %s column %s: %v", table.Name, field.DBName, err)
+				}
+				row[field.DBName] = value
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		dump.Tables = append(dump.Tables, table)
+	}
+
+	return dump, nil
+}
+
+// CheckDump checks whether the dump can be restored to the database, which
+// must be at the schema version of the dump.
+func CheckDump(db *gorm.DB, dump *Dump) error {
+	if dump.Header.Format == 0 || dump.Header.Format > DumpFormat {
+		return fmt.Errorf("unsupported dump format %d, the newest supported format is %d", dump.Header.Format, DumpFormat)
+	}
+
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	if dump.Header.SchemaVersion != current {
+		return fmt.Errorf("dump schema version %d does not match database schema version %d, run `cmdctl migrate to %d` first",
+			dump.Header.SchemaVersion, current, dump.Header.SchemaVersion)
+	}
+
+	known := make(map[string]bool, len(dumpModels))
+	for _, d := range dumpModelsAt(current) {
+		known[tableName(db, d.new())] = true
+	}
+	for _, t := range dump.Tables {
+		if !known[t.Name] {
+			return fmt.Errorf("unknown table %s in dump of schema version %d", t.Name, dump.Header.SchemaVersion)
+		}
+	}
+
+	return nil
+}
+
+// RestoreDatabase writes the rows of the dump in one transaction and returns
+// the number of rows restored per table. The tables must be empty unless
+// force is true, in which case the existing rows are deleted first.
+func RestoreDatabase(db *gorm.DB, dump *Dump, force bool) (map[string]int, error) {
+	if err := CheckDump(db, dump); err != nil {
+		return nil, err
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	counts, err := restoreTables(tx, dump, force)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return counts, tx.Commit().Error
+}
+
+func restoreTables(tx *gorm.DB, dump *Dump, force bool) (map[string]int, error) {
+	version := dump.Header.SchemaVersion
+	models := dumpModelsAt(version)
+
+	// delete in the reverse order so the referencing rows go first
+	for i := len(models) - 1; i >= 0; i-- {
+		m := models[i].new()
+		var count int
+		if err := tx.Unscoped().Model(m).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			continue
+		}
+		if !force {
+			return nil, fmt.Errorf("table %s is not empty, use --force to replace the existing rows", tableName(tx, m))
+		}
+		if err := tx.Unscoped().Delete(m).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[string]int, len(dump.Tables))
+	for _, d := range models {
+		name := tableName(tx, d.new())
+		table := dump.Table(name)
+		if table == nil {
+			continue
+		}
+
+		// the columns added by the later schema versions are left out
+		names := d.columnNames(tx, version)
+		for i, row := range table.Rows {
+			m := d.new()
+			if err := decodeRow(tx, d, m, row, version); err != nil {
+				return nil, fmt.Errorf("table %s row %d: %v", name, i+1, err)
+			}
+			if err := tx.Select(names).Create(m).Error; err != nil {
+				return nil, fmt.Errorf("table %s row %d: %v", name, i+1, err)
+			}
+		}
+		if err := resetSequence(tx, name); err != nil {
+			return nil, err
+		}
+		counts[name] = len(table.Rows)
+	}
+
+	return counts, nil
+}
+
+// decodeRow sets the fields of the model from the dumped row.
+func decodeRow(db *gorm.DB, d *dumpModel, m interface{}, row map[string]json.RawMessage, version uint) error {
+	fields := d.columns(db, m, version)
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.DBName] = true
+		value, ok := row[field.DBName]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, field.Field.Addr().Interface()); err != nil {
+			return fmt.Errorf("column %s: %v", field.DBName, err)
+		}
+	}
+
+	for column := range row {
+		if !known[column] {
+			return fmt.Errorf("unknown column %s", column)
+		}
+	}
+	return nil
+}
+
+// resetSequence moves the id sequence past the restored ids, postgres does not
+// do it when the ids are inserted explicitly.
+func resetSequence(tx *gorm.DB, table string) error {
+	if tx.Dialect().GetName() != "postgres" {
+		return nil
+	}
+
+	return tx.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %s",
+		table, tx.Dialect().Quote(table))).Error
+}
@@ -0,0 +1,22 @@
+package model
+
+// ConflictStrategy decides what to do when an imported user already exists.
+type ConflictStrategy string
+
+const (
+	// ConflictSkip keeps the existing user untouched.
+	ConflictSkip ConflictStrategy = "skip"
+	// ConflictOverwrite replaces the password, email and attributes of the
+	// existing user.
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	// ConflictFail treats an existing user as an error.
+	ConflictFail ConflictStrategy = "fail"
+)
+
+// ImportResult counts the users handled by UserStore.Import.
+type ImportResult struct {
+	Created int
+	Updated int
+	Skipped int
+	Failed  int
+}
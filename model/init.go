@@ -1,13 +1,9 @@
 package model
 
 import (
-	"fmt"
-	"log"
+	"cmdctl/pkg/config"
 
-	"github.com/spf13/viper"
-	// MySQL driver.
 	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/mysql"
 )
 
 type Database struct {
@@ -18,82 +14,97 @@ type Database struct {
 var DB *Database
 
 // setupDatabase initialize the database tables.
-func setupDatabase(db *gorm.DB) {
-	db.AutoMigrate(&UserModel{})
+func setupDatabase(db *gorm.DB) error {
+	return MigrateUp(db, nil)
 }
 
 // cleanDatabase tear downs the database tables.
-func cleanDatabase(db *gorm.DB) {
-	db.DropTable(&UserModel{})
+func cleanDatabase(db *gorm.DB) error {
+	return MigrateTo(db, 0, nil)
 }
 
 // resetDatabase resets the database tables.
-func resetDatabase(db *gorm.DB) {
-	cleanDatabase(db)
-	setupDatabase(db)
+func resetDatabase(db *gorm.DB) error {
+	if err := cleanDatabase(db); err != nil {
+		return err
+	}
+	return setupDatabase(db)
 }
 
-func openDB(username, password, addr, name string) *gorm.DB {
-	config := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8&parseTime=%t&loc=%s",
-		username,
-		password,
-		addr,
-		name,
-		true,
-		//"Asia/Shanghai"),
-		"Local")
-
-	db, err := gorm.Open("mysql", config)
+// OpenDB opens the database described by c, unlike the other helpers it
+// returns the error instead of exiting.
+func OpenDB(c *DBConfig) (*gorm.DB, error) {
+	driver, err := GetDriver(c.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(driver.Dialect(), driver.DSN(c))
 	if err != nil {
-		log.Fatalf("Database connection failed. Database name: %s, error: %v", name, err)
+		return nil, err
 	}
 
 	// set for db connection
 	setupDB(db)
 
-	return db
+	return db, nil
 }
 
-func setupDB(db *gorm.DB) {
-	// setup tables
-	setupDatabase(db)
+// quote quotes the column name for the dialect of db, postgres requires it
+// for the camel case columns such as createdAt.
+func quote(db *gorm.DB, column string) string {
+	return db.Dialect().Quote(column)
+}
 
-	db.LogMode(viper.GetBool("gormlog"))
-	//db.DB().SetMaxOpenConns(20000) // 用于设置最大打开的连接数，默认值为0表示不限制.设置最大的连接数，可以避免并发太高导致连接mysql出现too many connections的错误。
-	db.DB().SetMaxIdleConns(0) // 用于设置闲置的连接数.设置闲置的连接数则当开启的一个连接使用完成后可以放在池里等候下一次使用。
+// setupDB configures the connection, the tables are managed by the
+// migrations applied through `cmdctl init` and `cmdctl migrate`, and the
+// pool is configured by the datasource.
+func setupDB(db *gorm.DB) {
+	db.LogMode(config.Get().GormLog)
 }
 
 // used for cli
-func InitSelfDB() *gorm.DB {
-	return openDB(viper.GetString("db.username"),
-		viper.GetString("db.password"),
-		viper.GetString("db.addr"),
-		viper.GetString("db.name"))
+func InitSelfDB() (*gorm.DB, error) {
+	return Get(SelfDataSource)
 }
 
-func GetSelfDB() *gorm.DB {
+// GetSelfDB returns the shared connection of the self datasource, it is
+// closed on exit and should not be closed by the callers.
+func GetSelfDB() (*gorm.DB, error) {
 	return InitSelfDB()
 }
 
-func InitDockerDB() *gorm.DB {
-	return openDB(viper.GetString("db.username"),
-		viper.GetString("db.password"),
-		viper.GetString("db.addr"),
-		viper.GetString("db.name"))
+func InitDockerDB() (*gorm.DB, error) {
+	return Get(DockerDataSource)
 }
 
-func GetDockerDB() *gorm.DB {
+func GetDockerDB() (*gorm.DB, error) {
 	return InitDockerDB()
 }
 
-func (db *Database) Init() {
+// Init opens the self and docker datasources, the helpers of the package get
+// the connections from the datasources and do not depend on DB.
+func (db *Database) Init() error {
+	self, err := GetSelfDB()
+	if err != nil {
+		return err
+	}
+	docker, err := GetDockerDB()
+	if err != nil {
+		return err
+	}
+
 	DB = &Database{
-		Self:   GetSelfDB(),
-		Docker: GetDockerDB(),
+		Self:   self,
+		Docker: docker,
 	}
+	return nil
 }
 
+// Close closes the datasources and clears DB, whose connections are closed,
+// the next Init or Get opens them again. The commands leave it to the exit
+// hook.
 func (db *Database) Close() {
-	DB.Self.Close()
-	DB.Docker.Close()
+	CloseAll()
+	DB = nil
 }
@@ -0,0 +1,226 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migration is a versioned schema change, migrations are embedded in the
+// binary and applied in the order of their versions.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration in the database.
+type SchemaMigration struct {
+	Version   uint      `gorm:"primary_key;auto_increment:false;column:version"`
+	Name      string    `gorm:"column:name;not null"`
+	AppliedAt time.Time `gorm:"column:appliedAt"`
+}
+
+func (m *SchemaMigration) TableName() string {
+	return "tb_cmdctl_schema_migrations"
+}
+
+// MigrationStatus describes whether a migration has been applied.
+type MigrationStatus struct {
+	*Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var migrations []*Migration
+
+// registerMigration adds a migration, it panics if the version is used twice.
+func registerMigration(m *Migration) {
+	for _, existing := range migrations {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("model: migration version %d registered twice", m.Version))
+		}
+	}
+
+	migrations = append(migrations, m)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+}
+
+// LatestVersion returns the version of the newest migration.
+func LatestVersion() uint {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+func ensureMigrationTable(db *gorm.DB) error {
+	return db.AutoMigrate(&SchemaMigration{}).Error
+}
+
+func appliedMigrations(db *gorm.DB) (map[uint]*SchemaMigration, error) {
+	if err := ensureMigrationTable(db); err != nil {
+		return nil, err
+	}
+
+	records := make([]*SchemaMigration, 0)
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint]*SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// CurrentVersion returns the version of the newest applied migration, 0 means
+// no migration has been applied.
+func CurrentVersion(db *gorm.DB) (uint, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+
+	var version uint
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// GetMigrationStatus returns the status of all known migrations.
+func GetMigrationStatus(db *gorm.DB) ([]*MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]*MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := &MigrationStatus{Migration: m}
+		if r, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = r.AppliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// MigrateUp applies all the pending migrations.
+func MigrateUp(db *gorm.DB, fn func(m *Migration, up bool)) error {
+	return MigrateTo(db, LatestVersion(), fn)
+}
+
+// MigrateDown rolls back the latest steps applied migrations.
+func MigrateDown(db *gorm.DB, steps int, fn func(m *Migration, up bool)) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	var target uint
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[migrations[i].Version]; !ok {
+			continue
+		}
+		if steps == 0 {
+			target = migrations[i].Version
+			break
+		}
+		steps--
+	}
+
+	return MigrateTo(db, target, fn)
+}
+
+// MigrateTo migrates the schema up or down to the target version, every
+// migration runs in its own transaction together with its bookkeeping record.
+// fn is called after each migration is applied or rolled back.
+func MigrateTo(db *gorm.DB, target uint, fn func(m *Migration, up bool)) error {
+	if target != 0 && !hasMigration(target) {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	// apply the pending migrations up to the target in ascending order
+	for _, m := range migrations {
+		if m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := runMigration(db, m, true); err != nil {
+			return err
+		}
+		if fn != nil {
+			fn(m, true)
+		}
+	}
+
+	// roll back the applied migrations above the target in descending order
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= target {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if err := runMigration(db, m, false); err != nil {
+			return err
+		}
+		if fn != nil {
+			fn(m, false)
+		}
+	}
+
+	return nil
+}
+
+func hasMigration(version uint) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func runMigration(db *gorm.DB, m *Migration, up bool) error {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	var err error
+	if up {
+		if err = m.Up(tx); err == nil {
+			err = tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		}
+	} else {
+		if err = m.Down(tx); err == nil {
+			err = tx.Delete(&SchemaMigration{Version: m.Version}).Error
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d_%s failed: %v", m.Version, m.Name, err)
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// The structs below are snapshots of the tables at the time the migration was
+// written, so that old migrations keep working when the models change.
+
+type userV1 struct {
+	BaseModel
+	Username string `gorm:"column:username;not null"`
+	Password string `gorm:"column:password;not null"`
+	Email    string `gorm:"column:email"`
+}
+
+func (u *userV1) TableName() string {
+	return "tb_cmdctl_users"
+}
+
+type roleV2 struct {
+	BaseModel
+	Name        string `gorm:"column:name;not null;unique_index"`
+	Description string `gorm:"column:description"`
+}
+
+func (r *roleV2) TableName() string {
+	return "tb_cmdctl_roles"
+}
+
+type rolePermissionV2 struct {
+	Id         uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id"`
+	RoleId     uint64 `gorm:"column:roleId;not null;index"`
+	Permission string `gorm:"column:permission;not null"`
+}
+
+func (p *rolePermissionV2) TableName() string {
+	return "tb_cmdctl_role_permissions"
+}
+
+type roleBindingV2 struct {
+	Id       uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id"`
+	RoleId   uint64 `gorm:"column:roleId;not null;index"`
+	Username string `gorm:"column:username;not null;index"`
+}
+
+func (b *roleBindingV2) TableName() string {
+	return "tb_cmdctl_role_bindings"
+}
+
+type auditV3 struct {
+	Id         uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id"`
+	Command    string    `gorm:"column:command;not null;index"`
+	Args       string    `gorm:"column:args;type:text"`
+	Username   string    `gorm:"column:username;index"`
+	Host       string    `gorm:"column:host"`
+	StartedAt  time.Time `gorm:"column:startedAt;index"`
+	FinishedAt time.Time `gorm:"column:finishedAt"`
+	ExitCode   int       `gorm:"column:exitCode"`
+}
+
+func (a *auditV3) TableName() string {
+	return "tb_cmdctl_audit"
+}
+
+type userV4 struct {
+	BaseModel
+	Username   string `gorm:"column:username;not null"`
+	Password   string `gorm:"column:password;not null"`
+	Email      string `gorm:"column:email"`
+	Attributes string `gorm:"column:attributes;type:text"`
+}
+
+func (u *userV4) TableName() string {
+	return "tb_cmdctl_users"
+}
+
+func init() {
+	registerMigration(&Migration{
+		Version: 1,
+		Name:    "create_users",
+		// AutoMigrate keeps the databases created before migrations were introduced working
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&userV1{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists(&userV1{}).Error
+		},
+	})
+
+	registerMigration(&Migration{
+		Version: 2,
+		Name:    "create_roles",
+		Up: func(tx *gorm.DB) error {
+			return tx.CreateTable(&roleV2{}, &rolePermissionV2{}, &roleBindingV2{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists(&roleBindingV2{}, &rolePermissionV2{}, &roleV2{}).Error
+		},
+	})
+
+	registerMigration(&Migration{
+		Version: 3,
+		Name:    "create_audit",
+		Up: func(tx *gorm.DB) error {
+			return tx.CreateTable(&auditV3{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists(&auditV3{}).Error
+		},
+	})
+
+	registerMigration(&Migration{
+		Version: 4,
+		Name:    "add_user_attributes",
+		// AutoMigrate only adds the missing attributes column
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&userV4{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialect().GetName() == "sqlite3" {
+				return rebuildSQLiteTable(tx, &userV1{})
+			}
+			return tx.Model(&userV4{}).DropColumn("attributes").Error
+		},
+	})
+}
+
+// rebuildSQLiteTable drops the columns which are not in the model from its
+// table, since sqlite only supports DROP COLUMN as of 3.35. The table is
+// renamed, created again from the model and the rows are copied into it.
+func rebuildSQLiteTable(tx *gorm.DB, m interface{}) error {
+	scope := tx.NewScope(m)
+	table := scope.TableName()
+	old := table + "_old"
+	if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME TO %s", scope.Quote(table), scope.Quote(old))).Error; err != nil {
+		return err
+	}
+
+	// the names of the indexes are unique in the database and are kept by
+	// the renamed table, they are created again along with the table
+	rows, err := tx.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", old).Rows()
+	if err != nil {
+		return err
+	}
+	var indexes []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		indexes = append(indexes, name)
+	}
+	rows.Close()
+	for _, name := range indexes {
+		if err := tx.Exec(fmt.Sprintf("DROP INDEX %s", scope.Quote(name))).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.CreateTable(m).Error; err != nil {
+		return err
+	}
+
+	columns := make([]string, 0)
+	for _, field := range scope.Fields() {
+		if field.IsNormal && !field.IsIgnored {
+			columns = append(columns, scope.Quote(field.DBName))
+		}
+	}
+	list := strings.Join(columns, ", ")
+	if err := tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", scope.Quote(table), list, list, scope.Quote(old))).Error; err != nil {
+		return err
+	}
+
+	return tx.DropTable(old).Error
+}
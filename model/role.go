@@ -0,0 +1,212 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RoleModel represents a named set of permissions.
+type RoleModel struct {
+	BaseModel
+	Name        string `json:"name" gorm:"column:name;not null;unique_index"`
+	Description string `json:"description" gorm:"column:description"`
+}
+
+func (r *RoleModel) TableName() string {
+	return "tb_cmdctl_roles"
+}
+
+// RolePermissionModel grants a permission to a role. A permission is named as
+// `resource:verb`, `resource:*` grants all verbs of the resource and `*`
+// grants everything.
+type RolePermissionModel struct {
+	Id         uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id"`
+	RoleId     uint64 `gorm:"column:roleId;not null;index"`
+	Permission string `gorm:"column:permission;not null"`
+}
+
+func (p *RolePermissionModel) TableName() string {
+	return "tb_cmdctl_role_permissions"
+}
+
+// RoleBindingModel grants a role to a user.
+type RoleBindingModel struct {
+	Id       uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id"`
+	RoleId   uint64 `gorm:"column:roleId;not null;index"`
+	Username string `gorm:"column:username;not null;index"`
+}
+
+func (b *RoleBindingModel) TableName() string {
+	return "tb_cmdctl_role_bindings"
+}
+
+// RoleTablesExist reports whether the migration creating the tables of the
+// roles is applied, the permissions can not be checked before.
+func RoleTablesExist(db *gorm.DB) bool {
+	return db.HasTable(&RoleModel{}) && db.HasTable(&RolePermissionModel{}) && db.HasTable(&RoleBindingModel{})
+}
+
+// RoleInfo is a role along with its permissions and bound users.
+type RoleInfo struct {
+	*RoleModel
+	Permissions []string
+	Users       []string
+}
+
+// CreateRole creates a role with the permissions.
+func CreateRole(name, description string, permissions []string) error {
+	if _, err := GetRole(name); err == nil {
+		return fmt.Errorf("role %q already exists", name)
+	} else if !IsNotFound(err) {
+		return err
+	}
+
+	db, err := GetSelfDB()
+	if err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	role := &RoleModel{Name: name, Description: description}
+	if err := tx.Create(role).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, p := range permissions {
+		if err := tx.Create(&RolePermissionModel{RoleId: role.Id, Permission: p}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// GetRole gets a role by the name.
+func GetRole(name string) (*RoleModel, error) {
+	db, err := GetSelfDB()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RoleModel{}
+	d := db.Where("name = ?", name).First(r)
+	return r, d.Error
+}
+
+// BindRole grants the role to the user, it does nothing if already granted.
+func BindRole(name, username string) error {
+	role, err := GetRole(name)
+	if err != nil {
+		return err
+	}
+	if _, err := GetUser(username); err != nil {
+		return err
+	}
+
+	db, err := GetSelfDB()
+	if err != nil {
+		return err
+	}
+
+	binding := &RoleBindingModel{}
+	return db.Where(RoleBindingModel{RoleId: role.Id, Username: username}).FirstOrCreate(binding).Error
+}
+
+// UnbindRole revokes the role from the user.
+func UnbindRole(name, username string) error {
+	role, err := GetRole(name)
+	if err != nil {
+		return err
+	}
+
+	db, err := GetSelfDB()
+	if err != nil {
+		return err
+	}
+
+	d := db.Where(quote(db, "roleId")+" = ? AND username = ?", role.Id, username).Delete(&RoleBindingModel{})
+	if d.Error != nil {
+		return d.Error
+	}
+	if d.RowsAffected == 0 {
+		return fmt.Errorf("role %q is not bound to user %q", name, username)
+	}
+	return nil
+}
+
+// ListRoles lists all roles with their permissions and users.
+func ListRoles() ([]*RoleInfo, error) {
+	db, err := GetSelfDB()
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*RoleModel, 0)
+	if err := db.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	infos := make([]*RoleInfo, 0, len(roles))
+	for _, role := range roles {
+		info := &RoleInfo{RoleModel: role}
+
+		permissions := make([]*RolePermissionModel, 0)
+		if err := db.Where(quote(db, "roleId")+" = ?", role.Id).Order("permission").Find(&permissions).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range permissions {
+			info.Permissions = append(info.Permissions, p.Permission)
+		}
+
+		bindings := make([]*RoleBindingModel, 0)
+		if err := db.Where(quote(db, "roleId")+" = ?", role.Id).Order("username").Find(&bindings).Error; err != nil {
+			return nil, err
+		}
+		for _, b := range bindings {
+			info.Users = append(info.Users, b.Username)
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// HasPermission reports whether any role bound to the user grants the permission.
+func HasPermission(username, permission string) (bool, error) {
+	db, err := GetSelfDB()
+	if err != nil {
+		return false, err
+	}
+
+	granted := make([]*RolePermissionModel, 0)
+	err = db.Table((&RolePermissionModel{}).TableName()+" p").
+		Select("p.*").
+		Joins("JOIN "+(&RoleBindingModel{}).TableName()+" b ON b."+quote(db, "roleId")+" = p."+quote(db, "roleId")).
+		Where("b.username = ?", username).
+		Find(&granted).Error
+	if err != nil {
+		return false, err
+	}
+
+	for _, g := range granted {
+		if MatchPermission(g.Permission, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MatchPermission reports whether the granted permission covers the required one.
+func MatchPermission(granted, required string) bool {
+	if granted == "*" || granted == required {
+		return true
+	}
+	if strings.HasSuffix(granted, ":*") {
+		return strings.HasPrefix(required, strings.TrimSuffix(granted, "*"))
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// MemoryDriver is the `db.driver` which keeps the users in memory, it needs no
+// database server and is meant for tests and demos.
+const MemoryDriver = "memory"
+
+// ErrMemoryDriver is returned by the helpers which need a database connection,
+// such as those of the roles, the audit log, the migrations and the dumps,
+// since the memory driver keeps the users only.
+var ErrMemoryDriver = errors.New("the memory driver keeps the users only, this command needs a database driver")
+
+// UserStore is the storage of users, the commands access users through it
+// instead of the global database connection.
+type UserStore interface {
+	// Create creates a new user, the password is hashed if not yet.
+	Create(u *UserModel) error
+	// Get gets a user by the name.
+	Get(username string) (*UserModel, error)
+	// List lists the users matching opts along with the total number of them.
+	List(opts *ListUserOptions) ([]*UserModel, uint64, error)
+	// Walk walks through the users matching opts chunk by chunk, ordered by id.
+	// Offset and Limit are ignored and sorting by username is not supported.
+	Walk(opts *ListUserOptions, chunkSize int, fn func([]*UserModel) error) error
+	// Update saves the user, the password is hashed if not yet.
+	Update(u *UserModel) error
+	// Delete soft deletes the user by the name.
+	Delete(username string) error
+	// DeleteAll soft deletes all the users and returns the number of them.
+	DeleteAll() (int64, error)
+	// Restore restores the latest soft deleted user with the name.
+	Restore(username string) error
+	// Purge permanently deletes the users soft deleted before the time and
+	// returns them, nothing is deleted if dryRun is true.
+	Purge(before time.Time, dryRun bool) ([]*UserModel, error)
+	// Import creates the users atomically. onError is called with the index and
+	// the error of every user which can not be imported, the import goes on if
+	// it returns true, otherwise nothing is imported.
+	Import(users []*UserModel, strategy ConflictStrategy, onError func(i int, err error) bool) (*ImportResult, error)
+	// Rehash hashes the passwords stored in plain text and returns the number of them.
+	Rehash() (int, error)
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// NewUserStore returns the user store of the datasource.
+func NewUserStore(name string) (UserStore, error) {
+	c, err := LoadDataSourceConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	if c.Driver == MemoryDriver {
+		return memoryStore, nil
+	}
+
+	db, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &gormUserStore{db: db}, nil
+}
+
+// CheckPassword checks the plain text password of the user against the stored hash.
+func CheckPassword(store UserStore, username, password string) error {
+	u, err := store.Get(username)
+	if err != nil {
+		return err
+	}
+
+	if err := Compare(u.Password, password); err != nil {
+		return ErrPasswordMismatch
+	}
+
+	return nil
+}
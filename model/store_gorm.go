@@ -0,0 +1,324 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// gormUserStore stores the users in a sql database through gorm.
+type gormUserStore struct {
+	db *gorm.DB
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, '!' is used as the escape
+// character because backslash is handled differently by mysql, postgres and sqlite.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func (s *gormUserStore) quote(column string) string {
+	return s.db.Dialect().Quote(column)
+}
+
+// query builds the parameterized query of the conditions in opts.
+func (s *gormUserStore) query(opts *ListUserOptions) *gorm.DB {
+	query := s.db.Model(&UserModel{})
+	if opts.IncludeDeleted || opts.OnlyDeleted {
+		query = query.Unscoped()
+	}
+	if opts.OnlyDeleted {
+		query = query.Where(s.quote("deletedAt") + " IS NOT NULL")
+	}
+	if opts.Filter != "" {
+		query = query.Where("username LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(opts.Filter)+"%")
+	}
+	if opts.EmailDomain != "" {
+		domain := strings.TrimPrefix(opts.EmailDomain, "@")
+		query = query.Where("email LIKE ? ESCAPE '!'", "%@"+likeEscaper.Replace(domain))
+	}
+
+	return query
+}
+
+func (s *gormUserStore) order(opts *ListUserOptions) string {
+	if opts.SortBy == "" {
+		if opts.idDescending() {
+			return "id desc"
+		}
+		return "id"
+	}
+
+	order := s.quote(sortColumns[opts.SortBy])
+	if opts.Reverse {
+		order += " desc"
+	}
+	return order
+}
+
+func (s *gormUserStore) Create(u *UserModel) error {
+	if err := u.Encrypt(); err != nil {
+		return err
+	}
+
+	return s.db.Create(u).Error
+}
+
+func (s *gormUserStore) Get(username string) (*UserModel, error) {
+	u := &UserModel{}
+	d := s.db.Where("username = ?", username).First(u)
+	return u, d.Error
+}
+
+func (s *gormUserStore) List(opts *ListUserOptions) ([]*UserModel, uint64, error) {
+	users := make([]*UserModel, 0)
+	var count uint64
+
+	if err := opts.Validate(); err != nil {
+		return users, count, err
+	}
+
+	// the attributes are stored as json, which can not be queried in the same
+	// way by all the drivers, so they are matched after loading the users
+	if !opts.Selector.Empty() {
+		return s.listSelected(opts)
+	}
+
+	if err := s.query(opts).Count(&count).Error; err != nil {
+		return users, count, err
+	}
+
+	query := s.query(opts).Order(s.order(opts)).Offset(opts.Offset)
+	if opts.Limit > 0 {
+		query = query.Limit(opts.Limit)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return users, count, err
+	}
+
+	return users, count, nil
+}
+
+func (s *gormUserStore) listSelected(opts *ListUserOptions) ([]*UserModel, uint64, error) {
+	all := make([]*UserModel, 0)
+	if err := s.query(opts).Order(s.order(opts)).Find(&all).Error; err != nil {
+		return all, 0, err
+	}
+
+	users := selectUsers(all, opts)
+	return page(users, opts), uint64(len(users)), nil
+}
+
+// Walk paginates the users by id instead of offset, so large tables are
+// never loaded into memory at once.
+func (s *gormUserStore) Walk(opts *ListUserOptions, chunkSize int, fn func([]*UserModel) error) error {
+	if err := opts.validateWalk(chunkSize); err != nil {
+		return err
+	}
+
+	desc := opts.idDescending()
+	order := "id"
+	if desc {
+		order = "id desc"
+	}
+
+	var lastId uint64
+	for first := true; ; first = false {
+		users := make([]*UserModel, 0, chunkSize)
+		query := s.query(opts)
+		if !first {
+			if desc {
+				query = query.Where("id < ?", lastId)
+			} else {
+				query = query.Where("id > ?", lastId)
+			}
+		}
+
+		if err := query.Order(order).Limit(chunkSize).Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		if selected := selectUsers(users, opts); len(selected) > 0 {
+			if err := fn(selected); err != nil {
+				return err
+			}
+		}
+		if len(users) < chunkSize {
+			return nil
+		}
+
+		lastId = users[len(users)-1].Id
+	}
+}
+
+func (s *gormUserStore) Update(u *UserModel) error {
+	if err := u.Encrypt(); err != nil {
+		return err
+	}
+
+	return s.db.Save(u).Error
+}
+
+func (s *gormUserStore) Delete(username string) error {
+	d := s.db.Where("username = ?", username).Delete(&UserModel{})
+	if d.Error != nil {
+		return d.Error
+	}
+	if d.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (s *gormUserStore) DeleteAll() (int64, error) {
+	d := s.db.Delete(&UserModel{})
+	return d.RowsAffected, d.Error
+}
+
+func (s *gormUserStore) Restore(username string) error {
+	if _, err := s.Get(username); err == nil {
+		return fmt.Errorf("user %q already exists", username)
+	} else if !IsNotFound(err) {
+		return err
+	}
+
+	u := &UserModel{}
+	d := s.db.Unscoped().Where("username = ? AND "+s.quote("deletedAt")+" IS NOT NULL", username).Order("id desc").First(u)
+	if d.Error != nil {
+		return d.Error
+	}
+
+	return s.db.Unscoped().Model(u).UpdateColumn("deletedAt", gorm.Expr("NULL")).Error
+}
+
+func (s *gormUserStore) Purge(before time.Time, dryRun bool) ([]*UserModel, error) {
+	where := s.quote("deletedAt") + " IS NOT NULL AND " + s.quote("deletedAt") + " < ?"
+
+	users := make([]*UserModel, 0)
+	if err := s.db.Unscoped().Where(where, before).Order("id").Find(&users).Error; err != nil {
+		return users, err
+	}
+	if dryRun || len(users) == 0 {
+		return users, nil
+	}
+
+	return users, s.db.Unscoped().Where(where, before).Delete(&UserModel{}).Error
+}
+
+// importSavepoint wraps every user imported, so that a failed user is rolled
+// back alone. Postgres aborts the whole transaction on the first failed
+// statement otherwise.
+const importSavepoint = "cmdctl_import_user"
+
+// Import creates the users in one transaction.
+func (s *gormUserStore) Import(users []*UserModel, strategy ConflictStrategy, onError func(i int, err error) bool) (*ImportResult, error) {
+	result := &ImportResult{}
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return result, tx.Error
+	}
+
+	for i, u := range users {
+		if err := tx.Exec("SAVEPOINT " + importSavepoint).Error; err != nil {
+			tx.Rollback()
+			return result, err
+		}
+
+		err := importUser(tx, u, strategy, result)
+		if err != nil {
+			result.Failed++
+			if !onError(i, err) {
+				tx.Rollback()
+				return result, fmt.Errorf("import aborted, no user imported")
+			}
+			if err := tx.Exec("ROLLBACK TO SAVEPOINT " + importSavepoint).Error; err != nil {
+				tx.Rollback()
+				return result, err
+			}
+		}
+
+		if err := tx.Exec("RELEASE SAVEPOINT " + importSavepoint).Error; err != nil {
+			tx.Rollback()
+			return result, err
+		}
+	}
+
+	return result, tx.Commit().Error
+}
+
+func importUser(tx *gorm.DB, u *UserModel, strategy ConflictStrategy, result *ImportResult) error {
+	existing := &UserModel{}
+	err := tx.Where("username = ?", u.Username).First(existing).Error
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	if IsNotFound(err) {
+		if err := u.Encrypt(); err != nil {
+			return err
+		}
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		result.Created++
+		return nil
+	}
+
+	switch strategy {
+	case ConflictSkip:
+		result.Skipped++
+		return nil
+	case ConflictOverwrite:
+		existing.Password = u.Password
+		existing.Email = u.Email
+		existing.Attributes = u.Attributes
+		if err := existing.Encrypt(); err != nil {
+			return err
+		}
+		if err := tx.Save(existing).Error; err != nil {
+			return err
+		}
+		result.Updated++
+		return nil
+	}
+
+	return fmt.Errorf("user %q already exists", u.Username)
+}
+
+func (s *gormUserStore) Rehash() (int, error) {
+	users := make([]*UserModel, 0)
+	if err := s.db.Unscoped().Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	tx := s.db.Begin()
+	count := 0
+	for _, u := range users {
+		if IsEncrypted(u.Password) {
+			continue
+		}
+
+		hashed, err := Encrypt(u.Password)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		// UpdateColumn keeps `updatedAt` untouched
+		if err := tx.Unscoped().Model(u).UpdateColumn("password", hashed).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		count++
+	}
+
+	return count, tx.Commit().Error
+}
+
+// Close keeps the connection, it is shared by the datasource and closed on exit.
+func (s *gormUserStore) Close() error {
+	return nil
+}
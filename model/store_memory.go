@@ -0,0 +1,347 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// memoryStore is shared by the process, so the users outlive a single command
+// when several commands are run in the same process.
+var memoryStore = &memoryUserStore{}
+
+// memoryUserStore keeps the users in memory, it mirrors the behavior of the
+// gorm store including the soft deletion.
+type memoryUserStore struct {
+	mu     sync.Mutex
+	users  []*UserModel
+	nextId uint64
+}
+
+func cloneUser(u *UserModel) *UserModel {
+	c := *u
+	c.Attributes = u.Attributes.Clone()
+	if u.DeletedAt != nil {
+		t := *u.DeletedAt
+		c.DeletedAt = &t
+	}
+	return &c
+}
+
+func (s *memoryUserStore) match(u *UserModel, opts *ListUserOptions) bool {
+	deleted := u.DeletedAt != nil
+	if deleted && !opts.IncludeDeleted && !opts.OnlyDeleted {
+		return false
+	}
+	if !deleted && opts.OnlyDeleted {
+		return false
+	}
+	if opts.Filter != "" && !strings.Contains(u.Username, opts.Filter) {
+		return false
+	}
+	if opts.EmailDomain != "" && !strings.HasSuffix(u.Email, "@"+strings.TrimPrefix(opts.EmailDomain, "@")) {
+		return false
+	}
+	return opts.Selector.Matches(u.Attributes)
+}
+
+// find returns the matched users sorted as the gorm store does.
+func (s *memoryUserStore) find(opts *ListUserOptions) []*UserModel {
+	users := make([]*UserModel, 0)
+	for _, u := range s.users {
+		if s.match(u, opts) {
+			users = append(users, u)
+		}
+	}
+
+	less := func(i, j int) bool { return users[i].Id < users[j].Id }
+	reverse := opts.idDescending()
+	switch opts.SortBy {
+	case "username":
+		less = func(i, j int) bool { return users[i].Username < users[j].Username }
+		reverse = opts.Reverse
+	case "createdAt":
+		less = func(i, j int) bool { return users[i].CreatedAt.Before(users[j].CreatedAt) }
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		if reverse {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+
+	return users
+}
+
+func (s *memoryUserStore) get(username string) *UserModel {
+	for _, u := range s.users {
+		if u.Username == username && u.DeletedAt == nil {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *memoryUserStore) create(u *UserModel) error {
+	if err := u.Encrypt(); err != nil {
+		return err
+	}
+	if s.get(u.Username) != nil {
+		return fmt.Errorf("user %q already exists", u.Username)
+	}
+
+	now := time.Now()
+	s.nextId++
+	u.Id = s.nextId
+	u.CreatedAt, u.UpdatedAt = now, now
+	s.users = append(s.users, cloneUser(u))
+	return nil
+}
+
+func (s *memoryUserStore) Create(u *UserModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.create(u)
+}
+
+func (s *memoryUserStore) Get(username string) (*UserModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.get(username)
+	if u == nil {
+		return &UserModel{}, gorm.ErrRecordNotFound
+	}
+	return cloneUser(u), nil
+}
+
+func (s *memoryUserStore) List(opts *ListUserOptions) ([]*UserModel, uint64, error) {
+	if err := opts.Validate(); err != nil {
+		return make([]*UserModel, 0), 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matched := s.find(opts)
+	count := uint64(len(matched))
+	matched = page(matched, opts)
+
+	users := make([]*UserModel, 0, len(matched))
+	for _, u := range matched {
+		users = append(users, cloneUser(u))
+	}
+	return users, count, nil
+}
+
+func (s *memoryUserStore) Walk(opts *ListUserOptions, chunkSize int, fn func([]*UserModel) error) error {
+	if err := opts.validateWalk(chunkSize); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	matched := s.find(&ListUserOptions{
+		Filter:         opts.Filter,
+		EmailDomain:    opts.EmailDomain,
+		Reverse:        !opts.idDescending(),
+		IncludeDeleted: opts.IncludeDeleted,
+		OnlyDeleted:    opts.OnlyDeleted,
+		Selector:       opts.Selector,
+	})
+	users := make([]*UserModel, 0, len(matched))
+	for _, u := range matched {
+		users = append(users, cloneUser(u))
+	}
+	s.mu.Unlock()
+
+	for len(users) > 0 {
+		n := chunkSize
+		if n > len(users) {
+			n = len(users)
+		}
+		if err := fn(users[:n]); err != nil {
+			return err
+		}
+		users = users[n:]
+	}
+	return nil
+}
+
+func (s *memoryUserStore) Update(u *UserModel) error {
+	if err := u.Encrypt(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.users {
+		if existing.Id == u.Id {
+			u.UpdatedAt = time.Now()
+			s.users[i] = cloneUser(u)
+			return nil
+		}
+	}
+	return s.create(u)
+}
+
+func (s *memoryUserStore) Delete(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := false
+	now := time.Now()
+	for _, u := range s.users {
+		if u.Username == username && u.DeletedAt == nil {
+			u.DeletedAt = &now
+			deleted = true
+		}
+	}
+	if !deleted {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *memoryUserStore) DeleteAll() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	now := time.Now()
+	for _, u := range s.users {
+		if u.DeletedAt == nil {
+			u.DeletedAt = &now
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryUserStore) Restore(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.get(username) != nil {
+		return fmt.Errorf("user %q already exists", username)
+	}
+
+	for i := len(s.users) - 1; i >= 0; i-- {
+		if u := s.users[i]; u.Username == username && u.DeletedAt != nil {
+			u.DeletedAt = nil
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *memoryUserStore) Purge(before time.Time, dryRun bool) ([]*UserModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := make([]*UserModel, 0)
+	kept := make([]*UserModel, 0, len(s.users))
+	for _, u := range s.users {
+		if u.DeletedAt != nil && u.DeletedAt.Before(before) {
+			purged = append(purged, cloneUser(u))
+		} else {
+			kept = append(kept, u)
+		}
+	}
+	if !dryRun {
+		s.users = kept
+	}
+	return purged, nil
+}
+
+// Import works on a copy of the users, which is thrown away if the import
+// is aborted.
+func (s *memoryUserStore) Import(users []*UserModel, strategy ConflictStrategy, onError func(i int, err error) bool) (*ImportResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make([]*UserModel, 0, len(s.users))
+	for _, u := range s.users {
+		snapshot = append(snapshot, cloneUser(u))
+	}
+	nextId := s.nextId
+
+	result := &ImportResult{}
+	for i, u := range users {
+		err := s.importUser(u, strategy, result)
+		if err == nil {
+			continue
+		}
+
+		result.Failed++
+		if !onError(i, err) {
+			s.users, s.nextId = snapshot, nextId
+			return result, fmt.Errorf("import aborted, no user imported")
+		}
+	}
+
+	return result, nil
+}
+
+func (s *memoryUserStore) importUser(u *UserModel, strategy ConflictStrategy, result *ImportResult) error {
+	existing := s.get(u.Username)
+	if existing == nil {
+		if err := s.create(u); err != nil {
+			return err
+		}
+		result.Created++
+		return nil
+	}
+
+	switch strategy {
+	case ConflictSkip:
+		result.Skipped++
+		return nil
+	case ConflictOverwrite:
+		hashed := &UserModel{Password: u.Password}
+		if err := hashed.Encrypt(); err != nil {
+			return err
+		}
+		existing.Password = hashed.Password
+		existing.Email = u.Email
+		existing.Attributes = u.Attributes.Clone()
+		existing.UpdatedAt = time.Now()
+		result.Updated++
+		return nil
+	}
+
+	return fmt.Errorf("user %q already exists", u.Username)
+}
+
+func (s *memoryUserStore) Rehash() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hashed := make(map[*UserModel]string)
+	for _, u := range s.users {
+		if IsEncrypted(u.Password) {
+			continue
+		}
+
+		p, err := Encrypt(u.Password)
+		if err != nil {
+			return 0, err
+		}
+		hashed[u] = p
+	}
+
+	for u, p := range hashed {
+		u.Password = p
+	}
+	return len(hashed), nil
+}
+
+// Close keeps the users, they live as long as the process.
+func (s *memoryUserStore) Close() error {
+	return nil
+}
@@ -2,67 +2,181 @@ package model
 
 import (
 	"fmt"
+
+	"cmdctl/pkg/selector"
+
+	"github.com/jinzhu/gorm"
 )
 
 // User represents a registered user.
 type UserModel struct {
 	BaseModel
-	Username string `json:"username" gorm:"column:username;not null" binding:"required" validate:"min=1,max=32"`
-	Password string `json:"password" gorm:"column:password;not null" binding:"required" validate:"min=8,max=128"`
-	Email    string `gorm:"column:email"`
-	//Description `gorm:"column:description"`
+	Username string `json:"username" gorm:"column:username;not null" binding:"required" validate:"min=1,max=32,regexp=^[\\p{L}\\p{N}_.-]+$"`
+	Password string `json:"password" gorm:"column:password;not null" binding:"required" validate:"min=8,max=72"`
+	Email    string `gorm:"column:email" validate:"email"`
+	// Attributes replaces the planned description with arbitrary key/value pairs.
+	Attributes Attributes `json:"attributes,omitempty" gorm:"column:attributes;type:text"`
 }
 
 func (c *UserModel) TableName() string {
 	return "tb_cmdctl_users"
 }
 
+// Encrypt hashes the user password, it does nothing if the password is already hashed.
+func (u *UserModel) Encrypt() (err error) {
+	if IsEncrypted(u.Password) {
+		return nil
+	}
+
+	u.Password, err = Encrypt(u.Password)
+	return
+}
+
 // Create creates a new user account.
 func (u *UserModel) Create() error {
-	return DB.Self.Create(&u).Error
+	store, err := selfUserStore()
+	if err != nil {
+		return err
+	}
+	return store.Create(u)
 }
 
 // DeleteUser deletes the user by the user identifier.
 func DeleteUserById(id uint64) error {
+	db, err := GetSelfDB()
+	if err != nil {
+		return err
+	}
 	user := UserModel{}
 	user.BaseModel.Id = id
-	return DB.Self.Delete(&user).Error
+	return db.Delete(&user).Error
 }
 
+// DeleteUserByName deletes the user by the user name, gorm.ErrRecordNotFound
+// is returned if no such user exists.
 func DeleteUserByName(name string) error {
-	return DB.Self.Where("username = ?", name).Delete(&UserModel{}).Error
+	store, err := selfUserStore()
+	if err != nil {
+		return err
+	}
+	return store.Delete(name)
+}
+
+// IsNotFound returns true if the error means the record does not exist.
+func IsNotFound(err error) bool {
+	return err == gorm.ErrRecordNotFound
 }
 
 // Update updates an user account information.
 func (u *UserModel) Update() error {
-	return DB.Self.Save(u).Error
+	store, err := selfUserStore()
+	if err != nil {
+		return err
+	}
+	return store.Update(u)
 }
 
 // GetUser gets an user by the user identifier.
 func GetUser(username string) (*UserModel, error) {
-	u := &UserModel{}
-	d := DB.Self.Where(&UserModel{Username: username}).First(&u)
-	//d := DB.Self.Where("username = ?", username).First(&u)
-	return u, d.Error
+	store, err := selfUserStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(username)
+}
+
+// selfUserStore returns the user store of the self datasource, which is the
+// memory store for the memory driver. It needs no close, the gorm stores
+// share the connection of the datasource.
+func selfUserStore() (UserStore, error) {
+	return NewUserStore(SelfDataSource)
+}
+
+// ListUserOptions holds the conditions used to list users.
+type ListUserOptions struct {
+	// Filter matches the users whose username contains it.
+	Filter string
+	// EmailDomain matches the users whose email is under the domain.
+	EmailDomain string
+	Offset      int
+	// Limit is the max number of users returned, 0 means no limit.
+	Limit int
+	// SortBy is one of "username" or "createdAt", the newest users are
+	// listed first if it is empty.
+	SortBy  string
+	Reverse bool
+	// IncludeDeleted lists the soft deleted users along with the others.
+	IncludeDeleted bool
+	// OnlyDeleted lists the soft deleted users only.
+	OnlyDeleted bool
+	// Selector matches the attributes of the users, nil matches all.
+	Selector selector.Selector
+}
+
+var sortColumns = map[string]string{
+	"username":  "username",
+	"createdAt": "createdAt",
+}
+
+// Validate checks the options are supported by the user stores.
+func (opts *ListUserOptions) Validate() error {
+	if _, ok := sortColumns[opts.SortBy]; opts.SortBy != "" && !ok {
+		return fmt.Errorf("unsupported sort field %q, must be one of 'username' or 'createdAt'", opts.SortBy)
+	}
+	return nil
+}
+
+func (opts *ListUserOptions) validateWalk(chunkSize int) error {
+	if opts.SortBy == "username" {
+		return fmt.Errorf("walking users sorted by username is not supported")
+	}
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+	return opts.Validate()
 }
 
-// ListUser List all users
-func ListUser(username string, offset, limit int) ([]*UserModel, uint64, error) {
-	if limit == 0 {
-		limit = 1000
+// idDescending reports whether the users should be ordered by id descending.
+func (opts *ListUserOptions) idDescending() bool {
+	if opts.SortBy == "createdAt" {
+		return opts.Reverse
 	}
+	return !opts.Reverse
+}
 
-	users := make([]*UserModel, 0)
-	var count uint64
+// selectUsers returns the users whose attributes match the selector of opts.
+func selectUsers(users []*UserModel, opts *ListUserOptions) []*UserModel {
+	if opts.Selector.Empty() {
+		return users
+	}
 
-	where := fmt.Sprintf("username like '%%%s%%'", username)
-	if err := DB.Self.Model(&UserModel{}).Where(where).Count(&count).Error; err != nil {
-		return users, count, err
+	selected := make([]*UserModel, 0, len(users))
+	for _, u := range users {
+		if opts.Selector.Matches(u.Attributes) {
+			selected = append(selected, u)
+		}
 	}
+	return selected
+}
 
-	if err := DB.Self.Where(where).Offset(offset).Limit(limit).Order("id desc").Find(&users).Error; err != nil {
-		return users, count, err
+// page returns the users in the page described by the offset and limit of opts.
+func page(users []*UserModel, opts *ListUserOptions) []*UserModel {
+	if opts.Offset >= len(users) {
+		return users[:0]
+	}
+	users = users[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(users) {
+		users = users[:opts.Limit]
 	}
+	return users
+}
 
-	return users, count, nil
+// ListUser lists the users matching opts, the total number of matched users
+// is returned along with the users of the requested page.
+func ListUser(opts *ListUserOptions) ([]*UserModel, uint64, error) {
+	store, err := selfUserStore()
+	if err != nil {
+		return nil, 0, err
+	}
+	return store.List(opts)
 }
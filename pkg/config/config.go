@@ -0,0 +1,360 @@
+// Package config holds the typed cmdctl config, which is unmarshalled once
+// from the config read by viper, and its kubeconfig-style contexts: the named
+// servers, databases and credentials, and the contexts which pick one of each.
+//
+//	current-context: dev
+//	servers:
+//	  - name: local
+//	    server:
+//	      server: 127.0.0.1:6664
+//	      timeout: 2
+//	databases:
+//	  - name: local
+//	    database:
+//	      driver: mysql
+//	      addr: 127.0.0.1:3306
+//	      name: db_micro2
+//	credentials:
+//	  - name: micro
+//	    credential:
+//	      username: micro
+//	      password: micro
+//	contexts:
+//	  - name: dev
+//	    context:
+//	      server: local
+//	      database: local
+//	      credential: micro
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config is the cmdctl config, the json names of the fields are the keys of
+// the config file.
+type Config struct {
+	// DB is the database of the cmdctl tables if neither the current context
+	// nor datasources.self sets one.
+	DB          Database            `json:"db" mapstructure:"db"`
+	DataSources map[string]Database `json:"datasources,omitempty" mapstructure:"datasources"`
+	// FileServer is the file server if the current context does not set one.
+	FileServer FileServer `json:"fileserver" mapstructure:"fileserver"`
+	Password   Password   `json:"password" mapstructure:"password"`
+	RBAC       RBAC       `json:"rbac" mapstructure:"rbac"`
+	Auth       Auth       `json:"auth" mapstructure:"auth"`
+	// GormLog logs the sql statements.
+	GormLog bool `json:"gormlog" mapstructure:"gormlog"`
+
+	CurrentContext string            `json:"current-context,omitempty" mapstructure:"current-context"`
+	Servers        []NamedServer     `json:"servers,omitempty" mapstructure:"servers"`
+	Databases      []NamedDatabase   `json:"databases,omitempty" mapstructure:"databases"`
+	Credentials    []NamedCredential `json:"credentials,omitempty" mapstructure:"credentials"`
+	Contexts       []NamedContext    `json:"contexts,omitempty" mapstructure:"contexts"`
+}
+
+// FileServer is the legacy `fileserver` section.
+type FileServer struct {
+	Server string `json:"server" mapstructure:"server"`
+	// Timeout is the timeout in seconds of the requests, 0 means no timeout.
+	Timeout  int    `json:"timeout" mapstructure:"timeout" validate:"min=0"`
+	Username string `json:"username" mapstructure:"username"`
+	Password string `json:"password" mapstructure:"password"`
+}
+
+// Password configures the hashing of the user passwords.
+type Password struct {
+	// Cost is the bcrypt cost.
+	Cost int `json:"cost" mapstructure:"cost" validate:"min=4,max=31"`
+}
+
+// RBAC configures the permission checks of the mutating commands.
+type RBAC struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+}
+
+// Auth configures the acting user.
+type Auth struct {
+	// User takes precedence over the user logged in by `cmdctl login`. It is
+	// only honoured in the system, user or --config file, see TrustedOrigin.
+	User string `json:"user" mapstructure:"user"`
+}
+
+// Server is a file server endpoint.
+type Server struct {
+	// Server is the host:port of the file server.
+	Server string `json:"server" mapstructure:"server"`
+	// Timeout is the timeout in seconds of the requests.
+	Timeout int `json:"timeout,omitempty" mapstructure:"timeout" validate:"min=0"`
+}
+
+// Database holds the same settings as the `db` and `datasources` sections.
+type Database struct {
+	Driver       string `json:"driver,omitempty" mapstructure:"driver" validate:"oneof=mysql postgres sqlite3 memory"`
+	Username     string `json:"username,omitempty" mapstructure:"username"`
+	Password     string `json:"password,omitempty" mapstructure:"password"`
+	Addr         string `json:"addr,omitempty" mapstructure:"addr"`
+	Name         string `json:"name" mapstructure:"name"`
+	MaxOpenConns int    `json:"max_open_conns,omitempty" mapstructure:"max_open_conns" validate:"min=0"`
+	MaxIdleConns int    `json:"max_idle_conns,omitempty" mapstructure:"max_idle_conns" validate:"min=0"`
+	// ConnMaxLifetime is a duration such as 1h, 0 means forever.
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime,omitempty" mapstructure:"conn_max_lifetime" validate:"min=0"`
+}
+
+// Credential authenticates to the file server.
+type Credential struct {
+	Username string `json:"username" mapstructure:"username"`
+	Password string `json:"password,omitempty" mapstructure:"password"`
+}
+
+// Context picks a server, a database and a credential by their names, the
+// empty ones fall back to the legacy `fileserver` and `db` sections.
+type Context struct {
+	Server     string `json:"server,omitempty" mapstructure:"server"`
+	Database   string `json:"database,omitempty" mapstructure:"database"`
+	Credential string `json:"credential,omitempty" mapstructure:"credential"`
+}
+
+// NamedServer is a server with its name.
+type NamedServer struct {
+	Name   string `json:"name" mapstructure:"name"`
+	Server Server `json:"server" mapstructure:"server"`
+}
+
+// NamedDatabase is a database with its name.
+type NamedDatabase struct {
+	Name     string   `json:"name" mapstructure:"name"`
+	Database Database `json:"database" mapstructure:"database"`
+}
+
+// NamedCredential is a credential with its name.
+type NamedCredential struct {
+	Name       string     `json:"name" mapstructure:"name"`
+	Credential Credential `json:"credential" mapstructure:"credential"`
+}
+
+// NamedContext is a context with its name.
+type NamedContext struct {
+	Name    string  `json:"name" mapstructure:"name"`
+	Context Context `json:"context" mapstructure:"context"`
+}
+
+// Overrides are given by the global --context, --server, --database and
+// --credential flags, they take precedence over the current context.
+type Overrides struct {
+	Context    string
+	Server     string
+	Database   string
+	Credential string
+}
+
+var (
+	overrides    Overrides
+	overridesMux sync.Mutex
+)
+
+// SetOverrides sets the overrides used by CurrentContext.
+func SetOverrides(o Overrides) {
+	overridesMux.Lock()
+	defer overridesMux.Unlock()
+	overrides = o
+}
+
+// GetOverrides returns the overrides set by SetOverrides.
+func GetOverrides() Overrides {
+	overridesMux.Lock()
+	defer overridesMux.Unlock()
+	return overrides
+}
+
+// Default returns the config with the defaults, which are used for the keys
+// missing from the config file.
+func Default() *Config {
+	return &Config{
+		DB:         Database{Driver: "mysql"},
+		FileServer: FileServer{Timeout: 2},
+		Password:   Password{Cost: 10},
+	}
+}
+
+// SetDefaults registers the defaults with viper, so that the keys missing
+// from the config file are still read from the CMDCTL_* environment.
+func SetDefaults() {
+	for key, v := range defaultSettings() {
+		viper.SetDefault(key, v)
+	}
+}
+
+// defaultSettings returns the defaults by their dotted keys.
+func defaultSettings() map[string]interface{} {
+	settings := make(map[string]interface{})
+	collectDefaults("", reflect.ValueOf(Default()).Elem(), settings)
+	return settings
+}
+
+func collectDefaults(prefix string, value reflect.Value, settings map[string]interface{}) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := prefix + field.Tag.Get("mapstructure")
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			collectDefaults(key+".", value.Field(i), settings)
+		case reflect.Map, reflect.Slice:
+			// the named entries have no defaults
+		default:
+			settings[key] = value.Field(i).Interface()
+		}
+	}
+}
+
+var (
+	current    *Config
+	currentMux sync.Mutex
+)
+
+// Init unmarshals the config read by viper once for Get, the unknown keys
+// are rejected so that the typos in the config file are not ignored. Get
+// returns the keys which could be unmarshalled even if Init fails.
+func Init() error {
+	c := Default()
+	err := viper.UnmarshalExact(c)
+
+	currentMux.Lock()
+	defer currentMux.Unlock()
+	current = c
+	if err != nil {
+		return fmt.Errorf("invalid config: %v", err)
+	}
+	return nil
+}
+
+// Get returns the config unmarshalled by Init, or the defaults if Init was
+// not called.
+func Get() *Config {
+	currentMux.Lock()
+	defer currentMux.Unlock()
+	if current == nil {
+		current = Default()
+	}
+	return current
+}
+
+// Load unmarshals the config read by viper again, unlike Init it accepts
+// the unknown keys so that the config commands can fix an invalid config.
+func Load() (*Config, error) {
+	c := Default()
+	if err := viper.Unmarshal(c); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+	return c, nil
+}
+
+// ResolvedContext is the context in use, with the names resolved. The
+// pieces which are not set by the context are nil.
+type ResolvedContext struct {
+	Name           string
+	ServerName     string
+	Server         *Server
+	DatabaseName   string
+	Database       *Database
+	CredentialName string
+	Credential     *Credential
+}
+
+// CurrentContext resolves the context in use from the config returned by
+// Get and the overrides set by SetOverrides.
+func CurrentContext() (*ResolvedContext, error) {
+	return Get().Resolve(GetOverrides())
+}
+
+// Resolve returns the context given by the overrides or current-context,
+// with its pieces replaced by the overrides. An empty context is returned
+// if neither is set.
+func (c *Config) Resolve(o Overrides) (*ResolvedContext, error) {
+	resolved := &ResolvedContext{Name: c.CurrentContext}
+	if o.Context != "" {
+		resolved.Name = o.Context
+	}
+
+	if resolved.Name != "" {
+		context := c.Context(resolved.Name)
+		if context == nil {
+			return nil, fmt.Errorf("context %q not found", resolved.Name)
+		}
+		resolved.ServerName = context.Server
+		resolved.DatabaseName = context.Database
+		resolved.CredentialName = context.Credential
+	}
+
+	if o.Server != "" {
+		resolved.ServerName = o.Server
+	}
+	if o.Database != "" {
+		resolved.DatabaseName = o.Database
+	}
+	if o.Credential != "" {
+		resolved.CredentialName = o.Credential
+	}
+
+	if resolved.ServerName != "" {
+		if resolved.Server = c.Server(resolved.ServerName); resolved.Server == nil {
+			return nil, fmt.Errorf("server %q not found", resolved.ServerName)
+		}
+	}
+	if resolved.DatabaseName != "" {
+		if resolved.Database = c.Database(resolved.DatabaseName); resolved.Database == nil {
+			return nil, fmt.Errorf("database %q not found", resolved.DatabaseName)
+		}
+	}
+	if resolved.CredentialName != "" {
+		if resolved.Credential = c.Credential(resolved.CredentialName); resolved.Credential == nil {
+			return nil, fmt.Errorf("credential %q not found", resolved.CredentialName)
+		}
+	}
+	return resolved, nil
+}
+
+// Context returns the context of the name, or nil if not found.
+func (c *Config) Context(name string) *Context {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i].Context
+		}
+	}
+	return nil
+}
+
+// Server returns the server of the name, or nil if not found.
+func (c *Config) Server(name string) *Server {
+	for i := range c.Servers {
+		if c.Servers[i].Name == name {
+			return &c.Servers[i].Server
+		}
+	}
+	return nil
+}
+
+// Database returns the database of the name, or nil if not found.
+func (c *Config) Database(name string) *Database {
+	for i := range c.Databases {
+		if c.Databases[i].Name == name {
+			return &c.Databases[i].Database
+		}
+	}
+	return nil
+}
+
+// Credential returns the credential of the name, or nil if not found.
+func (c *Config) Credential(name string) *Credential {
+	for i := range c.Credentials {
+		if c.Credentials[i].Name == name {
+			return &c.Credentials[i].Credential
+		}
+	}
+	return nil
+}
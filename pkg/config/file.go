@@ -0,0 +1,312 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a config file being edited, the comments and the order of the
+// keys are kept when it is saved.
+type File struct {
+	Path string
+	doc  *yaml.Node
+}
+
+// LoadFile reads the config file, an empty config is returned if it does
+// not exist.
+func LoadFile(path string) (*File, error) {
+	f := &File{Path: path}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("error parsing %s: the config must be a mapping", path)
+	}
+	f.doc = &doc
+	return f, nil
+}
+
+// Save writes the config file.
+func (f *File) Save() error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f.doc); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	return WriteFileAtomic(f.Path, buf.Bytes())
+}
+
+// WriteFileAtomic replaces the file by writing a temp file in the same
+// directory and renaming it, so that the file is never left half written.
+// The mode of the file is kept, new files are only readable by the owner.
+func WriteFileAtomic(path string, data []byte) error {
+	// replace the target of a symlink instead of the symlink
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	// a no-op once renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get returns the value of the dotted key, such as db.addr or
+// contexts.0.name, or nil if it is not set.
+func (f *File) Get(key string) *yaml.Node {
+	node := f.root()
+	for _, segment := range strings.Split(key, ".") {
+		if node = child(node, segment); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Set sets the dotted key to the value, which is parsed as yaml so that
+// numbers and booleans keep their types. The missing mappings are created.
+func (f *File) Set(key, v string) error {
+	segments := strings.Split(key, ".")
+	node := f.root()
+	for i, segment := range segments[:len(segments)-1] {
+		next := child(node, segment)
+		if next == nil || (next.Kind != yaml.MappingNode && next.Kind != yaml.SequenceNode) {
+			if node.Kind != yaml.MappingNode {
+				return fmt.Errorf("cannot set %s: %s is not a mapping", key, strings.Join(segments[:i], "."))
+			}
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			setKey(node, segment, next)
+		}
+		node = next
+	}
+
+	last := segments[len(segments)-1]
+	parsed := parseValue(v)
+	switch node.Kind {
+	case yaml.MappingNode:
+		setKey(node, last, parsed)
+	case yaml.SequenceNode:
+		index, err := strconv.Atoi(last)
+		if err != nil || index < 0 || index >= len(node.Content) {
+			return fmt.Errorf("cannot set %s: invalid index %s", key, last)
+		}
+		node.Content[index] = parsed
+	}
+	return nil
+}
+
+// Unset removes the dotted key, it returns false if it is not set.
+func (f *File) Unset(key string) bool {
+	segments := strings.Split(key, ".")
+	parent := f.root()
+	if len(segments) > 1 {
+		if parent = f.Get(strings.Join(segments[:len(segments)-1], ".")); parent == nil {
+			return false
+		}
+	}
+
+	last := segments[len(segments)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		if value(parent, last) == nil {
+			return false
+		}
+		deleteKey(parent, last)
+		return true
+	case yaml.SequenceNode:
+		index, err := strconv.Atoi(last)
+		if err != nil || index < 0 || index >= len(parent.Content) {
+			return false
+		}
+		parent.Content = append(parent.Content[:index], parent.Content[index+1:]...)
+		return true
+	}
+	return false
+}
+
+// child returns the value of the key of a mapping or the element of a
+// sequence, or nil.
+func child(node *yaml.Node, segment string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return value(node, segment)
+	case yaml.SequenceNode:
+		index, err := strconv.Atoi(segment)
+		if err != nil || index < 0 || index >= len(node.Content) {
+			return nil
+		}
+		return node.Content[index]
+	}
+	return nil
+}
+
+// parseValue parses the value as yaml, it is kept as a string if it is not
+// valid yaml.
+func parseValue(v string) *yaml.Node {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(v), &doc); err != nil || len(doc.Content) == 0 {
+		return scalar(v)
+	}
+	return doc.Content[0]
+}
+
+func (f *File) root() *yaml.Node {
+	return f.doc.Content[0]
+}
+
+// SetCurrentContext sets current-context, it is removed if name is empty.
+func (f *File) SetCurrentContext(name string) {
+	if name == "" {
+		deleteKey(f.root(), "current-context")
+		return
+	}
+	setKey(f.root(), "current-context", scalar(name))
+}
+
+// SetContext creates or updates the context, the empty fields of context are
+// left unchanged.
+func (f *File) SetContext(name string, context Context) {
+	fields := mapping(namedItem(f.root(), "contexts", name), "context")
+	for _, field := range []struct{ key, value string }{
+		{"server", context.Server},
+		{"database", context.Database},
+		{"credential", context.Credential},
+	} {
+		if field.value != "" {
+			setKey(fields, field.key, scalar(field.value))
+		}
+	}
+}
+
+// DeleteContext deletes the context, it returns false if not found.
+func (f *File) DeleteContext(name string) bool {
+	list := value(f.root(), "contexts")
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return false
+	}
+	for i, item := range list.Content {
+		if n := value(item, "name"); n != nil && n.Value == name {
+			list.Content = append(list.Content[:i], list.Content[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// value returns the value of the key in the mapping node, or nil.
+func value(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setKey sets the value of the key in place, or appends the key.
+func setKey(node *yaml.Node, key string, v *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			// keep the comments of the old value
+			v.LineComment = node.Content[i+1].LineComment
+			node.Content[i+1] = v
+			return
+		}
+	}
+	node.Content = append(node.Content, scalar(key), v)
+}
+
+func deleteKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// mapping returns the mapping under the key, which is created if missing.
+func mapping(node *yaml.Node, key string) *yaml.Node {
+	v := value(node, key)
+	if v == nil || v.Kind != yaml.MappingNode {
+		v = &yaml.Node{Kind: yaml.MappingNode}
+		setKey(node, key, v)
+	}
+	return v
+}
+
+// namedItem returns the item with the name in the list under the key, it is
+// appended if missing.
+func namedItem(node *yaml.Node, key, name string) *yaml.Node {
+	list := value(node, key)
+	if list == nil || list.Kind != yaml.SequenceNode {
+		list = &yaml.Node{Kind: yaml.SequenceNode}
+		setKey(node, key, list)
+	}
+
+	for _, item := range list.Content {
+		if n := value(item, "name"); n != nil && n.Value == name {
+			return item
+		}
+	}
+
+	item := &yaml.Node{Kind: yaml.MappingNode}
+	setKey(item, "name", scalar(name))
+	list.Content = append(list.Content, item)
+	return item
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"cmdctl/pkg/homedir"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config files.
+const FileName = "cmdctl.yaml"
+
+// IncludeKey lists the files merged below the file which includes them,
+// such as `include: [../shared/fileserver.yaml, conf.d/*.yaml]`. The paths
+// are relative to the including file.
+const IncludeKey = "include"
+
+// The scopes of the config files.
+const (
+	ScopeSystem  = "system"
+	ScopeUser    = "user"
+	ScopeProject = "project"
+	// ScopeFlag is the file given by --config, which replaces the others.
+	ScopeFlag    = "flag"
+	ScopeInclude = "include"
+)
+
+// Source is a config file read by ReadInConfig.
+type Source struct {
+	Path  string
+	Scope string
+	// IncludedBy is the path of the file which includes it.
+	IncludedBy string
+}
+
+// SystemFile returns the config file shared by the users of the machine.
+func SystemFile() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "cmdctl", FileName)
+	}
+	return filepath.Join("/etc/cmdctl", FileName)
+}
+
+// ProjectFile returns the cmdctl.yaml of the current directory or of its
+// closest parent, or empty if there is none. The system and user files are
+// never project files.
+func ProjectFile(userFile string) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && !samePath(path, userFile) && !samePath(path, SystemFile()) {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+var (
+	sources    []Source
+	sourcesMux sync.Mutex
+)
+
+// Sources returns the files read by ReadInConfig, from the lowest to the
+// highest precedence. The included files come before the file including them.
+func Sources() []Source {
+	sourcesMux.Lock()
+	defer sourcesMux.Unlock()
+	return append([]Source{}, sources...)
+}
+
+// ReadInConfig merges the config files into viper, from the lowest to the
+// highest precedence: the system file, the user file and the project file.
+// The file given by --config replaces them. The maps are merged key by key,
+// the lists and the other values of a file replace those of the files below.
+func ReadInConfig(explicit, userFile string) error {
+	var files []Source
+	if explicit != "" {
+		files = append(files, Source{Path: explicit, Scope: ScopeFlag})
+	} else {
+		for _, s := range []Source{
+			{Path: SystemFile(), Scope: ScopeSystem},
+			{Path: userFile, Scope: ScopeUser},
+			{Path: ProjectFile(userFile), Scope: ScopeProject},
+		} {
+			if s.Path == "" {
+				continue
+			}
+			if _, err := os.Stat(s.Path); err == nil {
+				files = append(files, s)
+			}
+		}
+	}
+
+	// the files after an invalid one are still read, so that config
+	// validate checks all of them
+	r := &reader{}
+	var err error
+	for _, f := range files {
+		if e := r.read(f, nil); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	sourcesMux.Lock()
+	defer sourcesMux.Unlock()
+	sources = r.sources
+	return err
+}
+
+type reader struct {
+	sources []Source
+}
+
+// read merges the includes of the file and then the file, stack holds the
+// files including it to detect the cycles.
+func (r *reader) read(source Source, stack []string) error {
+	path, err := filepath.Abs(source.Path)
+	if err != nil {
+		return err
+	}
+	for _, p := range stack {
+		if samePath(p, path) {
+			return fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), path)
+		}
+	}
+
+	data, err := ioutil.ReadFile(source.Path)
+	if err != nil {
+		if source.IncludedBy != "" {
+			return fmt.Errorf("error reading %s included by %s: %v", source.Path, source.IncludedBy, err)
+		}
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		r.sources = append(r.sources, source)
+		return fmt.Errorf("error parsing %s: %v", source.Path, err)
+	}
+
+	includes, err := includePaths(source.Path, values[IncludeKey])
+	if err != nil {
+		r.sources = append(r.sources, source)
+		return err
+	}
+	delete(values, IncludeKey)
+	for _, include := range includes {
+		if err := r.read(Source{Path: include, Scope: ScopeInclude, IncludedBy: source.Path}, append(stack, path)); err != nil {
+			r.sources = append(r.sources, source)
+			return err
+		}
+	}
+
+	if err := viper.MergeConfigMap(values); err != nil {
+		return fmt.Errorf("error merging %s: %v", source.Path, err)
+	}
+	r.sources = append(r.sources, source)
+	return nil
+}
+
+// includePaths resolves the include directive of the file, which is a path
+// or a list of paths. A leading ~ is the home directory, and the patterns
+// such as conf.d/*.yaml are expanded in order.
+func includePaths(file string, include interface{}) ([]string, error) {
+	var patterns []string
+	switch include := include.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		patterns = []string{include}
+	case []interface{}:
+		for _, item := range include {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s in %s: %v is not a path", IncludeKey, file, item)
+			}
+			patterns = append(patterns, s)
+		}
+	default:
+		return nil, fmt.Errorf("invalid %s in %s: must be a path or a list of paths", IncludeKey, file)
+	}
+
+	var paths []string
+	for _, pattern := range patterns {
+		pattern = resolveInclude(file, pattern)
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in %s: %v", IncludeKey, file, err)
+		}
+		sort.Strings(matches)
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// resolveInclude returns the path of an include relative to the file.
+func resolveInclude(file, path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(homedir.HomeDir(), path[1:])
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(file), path)
+}
+
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if absA, err := filepath.Abs(a); err == nil {
+		a = absA
+	}
+	if absB, err := filepath.Abs(b); err == nil {
+		b = absB
+	}
+	return a == b
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestReadInConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		// want are the values of the keys read by viper
+		want map[string]interface{}
+		// wantSources are the files read, relative to the temp dir
+		wantSources []string
+		// wantErr is a part of the error, or empty if it succeeds
+		wantErr string
+	}{
+		{
+			name: "include below the including file",
+			files: map[string]string{
+				FileName:      "include: shared.yaml\ndb:\n  addr: main:1\n",
+				"shared.yaml": "db:\n  addr: shared:1\n  name: shared\n",
+			},
+			want:        map[string]interface{}{"db.addr": "main:1", "db.name": "shared"},
+			wantSources: []string{"shared.yaml", FileName},
+		},
+		{
+			name: "patterns in order",
+			files: map[string]string{
+				FileName:          "include: [conf.d/*.yaml]\n",
+				"conf.d/b.yaml":   "db:\n  addr: b:1\n",
+				"conf.d/a.yaml":   "db:\n  addr: a:1\n  name: a\n",
+				"conf.d/c.yml.bk": "db:\n  name: c\n",
+			},
+			want:        map[string]interface{}{"db.addr": "b:1", "db.name": "a"},
+			wantSources: []string{"conf.d/a.yaml", "conf.d/b.yaml", FileName},
+		},
+		{
+			name: "nested includes relative to the including file",
+			files: map[string]string{
+				FileName:          "include: [conf.d/a.yaml]\n",
+				"conf.d/a.yaml":   "include: [../shared/b.yaml]\n",
+				"shared/b.yaml":   "db:\n  name: b\n",
+				"shared/c.yaml":   "db:\n  name: c\n",
+				"conf.d/unused.y": "db:\n  name: unused\n",
+			},
+			want:        map[string]interface{}{"db.name": "b"},
+			wantSources: []string{"shared/b.yaml", "conf.d/a.yaml", FileName},
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				FileName: "include: a.yaml\n",
+				"a.yaml": "include: b.yaml\n",
+				"b.yaml": "include: a.yaml\n",
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "file including itself",
+			files: map[string]string{
+				FileName: "include: " + FileName + "\n",
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				FileName: "include: missing.yaml\n",
+			},
+			wantErr: "included by",
+		},
+		{
+			name: "invalid include",
+			files: map[string]string{
+				FileName: "include:\n  path: a.yaml\n",
+			},
+			wantErr: "must be a path or a list of paths",
+		},
+	}
+
+	for _, tt := range tests {
+		dir := writeFiles(t, tt.files)
+		viper.Reset()
+		err := ReadInConfig(filepath.Join(dir, FileName), "")
+		sources := Sources()
+		values := make(map[string]interface{})
+		for key := range tt.want {
+			values[key] = viper.Get(key)
+		}
+		os.RemoveAll(dir)
+
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: ReadInConfig() = %v, want an error containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ReadInConfig() returned error: %v", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(values, tt.want) {
+			t.Errorf("%s: values = %v, want %v", tt.name, values, tt.want)
+		}
+		var got []string
+		for _, s := range sources {
+			rel, _ := filepath.Rel(dir, s.Path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !reflect.DeepEqual(got, tt.wantSources) {
+			t.Errorf("%s: Sources() = %v, want %v", tt.name, got, tt.wantSources)
+		}
+		if sources[len(sources)-1].Scope != ScopeFlag {
+			t.Errorf("%s: scope of %s = %s, want %s", tt.name, got[len(got)-1], sources[len(sources)-1].Scope, ScopeFlag)
+		}
+		for _, s := range sources[:len(sources)-1] {
+			if s.Scope != ScopeInclude || s.IncludedBy == "" {
+				t.Errorf("%s: source %+v, want an included file", tt.name, s)
+			}
+		}
+	}
+	viper.Reset()
+}
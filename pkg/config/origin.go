@@ -0,0 +1,286 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variables which override the config,
+// such as CMDCTL_DB_ADDR for db.addr.
+const EnvPrefix = "CMDCTL"
+
+// EnvKeyReplacer turns the dotted keys into environment variable names.
+var EnvKeyReplacer = strings.NewReplacer(".", "_")
+
+// EnvVar returns the environment variable which overrides the key.
+func EnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(EnvKeyReplacer.Replace(key))
+}
+
+// The kinds of the origins of the settings.
+const (
+	OriginDefault = "default"
+	OriginFile    = "file"
+	OriginEnv     = "env"
+	OriginFlag    = "flag"
+)
+
+// Origin is where a setting comes from.
+type Origin struct {
+	Kind string `json:"kind"`
+	// Source is the path of the file, the name of the environment variable
+	// or the name of the flag.
+	Source string `json:"source,omitempty"`
+	// Line is the line of the key in the file.
+	Line int `json:"line,omitempty"`
+}
+
+func (o Origin) String() string {
+	switch o.Kind {
+	case OriginFile:
+		if o.Line > 0 {
+			return fmt.Sprintf("%s:%d", o.Source, o.Line)
+		}
+		return o.Source
+	case OriginEnv:
+		return "env " + o.Source
+	case OriginFlag:
+		return "flag " + o.Source
+	}
+	return o.Kind
+}
+
+// Setting is the value of a dotted key set by a layer.
+type Setting struct {
+	Key    string
+	Value  interface{}
+	Origin Origin
+}
+
+// Layer is a source of the config, with the settings it sets sorted by key.
+type Layer struct {
+	Origin   Origin
+	Settings []Setting
+	// Values are the settings as nested maps, as they are written in a file.
+	Values map[string]interface{}
+}
+
+// Layers returns the sources of the config read by viper, from the lowest
+// to the highest precedence: the defaults, the config files in the order of
+// Sources, the CMDCTL_* environment variables and the flags. The layers which set nothing are
+// left out.
+func Layers() ([]*Layer, error) {
+	var layers []*Layer
+
+	defaults := &Layer{Origin: Origin{Kind: OriginDefault}}
+	for key, v := range defaultSettings() {
+		defaults.Settings = append(defaults.Settings, Setting{Key: key, Value: v, Origin: defaults.Origin})
+	}
+	layers = append(layers, defaults)
+
+	for _, source := range Sources() {
+		layer, err := fileLayer(source.Path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	// AutomaticEnv applies to the keys viper knows, the lists are not split
+	env := &Layer{Origin: Origin{Kind: OriginEnv}}
+	for _, key := range viper.AllKeys() {
+		name := EnvVar(key)
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			env.Settings = append(env.Settings, Setting{Key: key, Value: v, Origin: Origin{Kind: OriginEnv, Source: name}})
+		}
+	}
+	layers = append(layers, env)
+
+	flags := &Layer{Origin: Origin{Kind: OriginFlag}}
+	if o := GetOverrides(); o.Context != "" {
+		flags.Settings = append(flags.Settings, Setting{Key: "current-context", Value: o.Context, Origin: Origin{Kind: OriginFlag, Source: "--context"}})
+	}
+	layers = append(layers, flags)
+
+	result := make([]*Layer, 0, len(layers))
+	for _, layer := range layers {
+		if len(layer.Settings) == 0 {
+			continue
+		}
+		sort.Slice(layer.Settings, func(i, j int) bool { return layer.Settings[i].Key < layer.Settings[j].Key })
+		if layer.Values == nil {
+			layer.Values = nest(layer.Settings)
+		}
+		result = append(result, layer)
+	}
+	return result, nil
+}
+
+// fileLayer reads the settings of a config file with their lines, the
+// items of the lists are keyed by their indexes, such as contexts.0.name.
+// The include directive is left out, the included files are layers too.
+func fileLayer(path string) (*Layer, error) {
+	f, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	layer := &Layer{Origin: Origin{Kind: OriginFile, Source: path}}
+	if err := f.root().Decode(&layer.Values); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	delete(layer.Values, IncludeKey)
+
+	var settings []Setting
+	flatten("", f.root(), path, &settings)
+	for _, s := range settings {
+		if s.Key != IncludeKey && !strings.HasPrefix(s.Key, IncludeKey+".") {
+			layer.Settings = append(layer.Settings, s)
+		}
+	}
+	return layer, nil
+}
+
+func flatten(key string, node *yaml.Node, path string, settings *[]Setting) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch {
+	case node.Kind == yaml.MappingNode && len(node.Content) > 0:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			flatten(joinKey(key, strings.ToLower(node.Content[i].Value)), node.Content[i+1], path, settings)
+		}
+	case node.Kind == yaml.SequenceNode && len(node.Content) > 0:
+		for i, item := range node.Content {
+			flatten(joinKey(key, strconv.Itoa(i)), item, path, settings)
+		}
+	default:
+		var v interface{}
+		node.Decode(&v)
+		*settings = append(*settings, Setting{
+			Key:    key,
+			Value:  v,
+			Origin: Origin{Kind: OriginFile, Source: path, Line: node.Line},
+		})
+	}
+}
+
+// nest turns the dotted keys into nested maps.
+func nest(settings []Setting) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, s := range settings {
+		m := values
+		segments := strings.Split(s.Key, ".")
+		for _, segment := range segments[:len(segments)-1] {
+			next, ok := m[segment].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				m[segment] = next
+			}
+			m = next
+		}
+		m[segments[len(segments)-1]] = s.Value
+	}
+	return values
+}
+
+// Merged returns the setting in effect for every key, sorted by key. The
+// settings of the later layers take precedence, and a list replaces the
+// items of the list of the layers below as viper.MergeConfigMap does.
+func Merged(layers []*Layer) []Setting {
+	effective := make(map[string]Setting)
+	for _, layer := range layers {
+		for _, list := range listKeys(layer.Settings) {
+			for key := range effective {
+				if strings.HasPrefix(key, list+".") {
+					delete(effective, key)
+				}
+			}
+		}
+		for _, s := range layer.Settings {
+			if _, section := s.Value.(map[string]interface{}); section || s.Value == nil {
+				// an empty section is merged with the keys under it
+				if _, ok := effective[s.Key]; !ok && hasKeyUnder(effective, s.Key) {
+					continue
+				}
+			}
+			// a key replaces the keys under it, such as a list set by env
+			for key := range effective {
+				if strings.HasPrefix(key, s.Key+".") || strings.HasPrefix(s.Key, key+".") {
+					delete(effective, key)
+				}
+			}
+			effective[s.Key] = s
+		}
+	}
+
+	settings := make([]Setting, 0, len(effective))
+	for _, s := range effective {
+		settings = append(settings, s)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// listKey returns the key of the outermost list the key is an item of, such
+// as contexts for contexts.1.name, or empty if it is not in a list.
+func listKey(key string) string {
+	segments := strings.Split(key, ".")
+	for i := 1; i < len(segments); i++ {
+		if _, err := strconv.Atoi(segments[i]); err == nil {
+			return strings.Join(segments[:i], ".")
+		}
+	}
+	return ""
+}
+
+// listKeys returns the keys of the lists the settings are items of.
+func listKeys(settings []Setting) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, s := range settings {
+		if list := listKey(s.Key); list != "" && !seen[list] {
+			seen[list] = true
+			keys = append(keys, list)
+		}
+	}
+	return keys
+}
+
+func hasKeyUnder(settings map[string]Setting, key string) bool {
+	for k := range settings {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// OverriddenBy returns the origin of the merged setting which takes
+// precedence over s, ok is false if s is in effect.
+func OverriddenBy(merged []Setting, s Setting) (origin Origin, ok bool) {
+	for _, m := range merged {
+		if m.Key == s.Key || strings.HasPrefix(m.Key, s.Key+".") || strings.HasPrefix(s.Key, m.Key+".") {
+			if m.Key == s.Key && m.Origin == s.Origin {
+				return Origin{}, false
+			}
+			return m.Origin, true
+		}
+	}
+	// the items are dropped if a later layer sets a shorter list
+	if list := listKey(s.Key); list != "" {
+		for _, m := range merged {
+			if m.Key == list || strings.HasPrefix(m.Key, list+".") {
+				return m.Origin, true
+			}
+		}
+	}
+	return Origin{}, false
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// layer returns a layer of the file with the settings given as key, value pairs.
+func layer(file string, kv ...interface{}) *Layer {
+	l := &Layer{Origin: Origin{Kind: OriginFile, Source: file}}
+	for i := 0; i+1 < len(kv); i += 2 {
+		l.Settings = append(l.Settings, Setting{Key: kv[i].(string), Value: kv[i+1], Origin: l.Origin})
+	}
+	return l
+}
+
+func TestMerged(t *testing.T) {
+	tests := []struct {
+		name   string
+		layers []*Layer
+		// want maps the keys in effect to the file setting them
+		want map[string]string
+	}{
+		{
+			name:   "later layer wins",
+			layers: []*Layer{layer("a", "db.addr", "a:1", "db.name", "cmdctl"), layer("b", "db.addr", "b:1")},
+			want:   map[string]string{"db.addr": "b", "db.name": "a"},
+		},
+		{
+			name: "shorter list replaces the items",
+			layers: []*Layer{
+				layer("a", "servers.0.name", "one", "servers.1.name", "two", "servers.1.server.server", "http://two"),
+				layer("b", "servers.0.name", "three"),
+			},
+			want: map[string]string{"servers.0.name": "b"},
+		},
+		{
+			name: "items are not merged field by field",
+			layers: []*Layer{
+				layer("a", "contexts.0.name", "dev", "contexts.0.context.server", "local"),
+				layer("b", "contexts.0.name", "prod"),
+			},
+			want: map[string]string{"contexts.0.name": "b"},
+		},
+		{
+			name: "list of a map entry",
+			layers: []*Layer{
+				layer("a", "datasources.reports.addr", "a:1", "include.0", "x.yaml", "include.1", "y.yaml"),
+				layer("b", "include.0", "z.yaml"),
+			},
+			want: map[string]string{"datasources.reports.addr": "a", "include.0": "b"},
+		},
+		{
+			name: "scalar replaces a section",
+			layers: []*Layer{
+				layer("a", "db.addr", "a:1", "db.name", "cmdctl"),
+				layer("b", "db", "sqlite"),
+			},
+			want: map[string]string{"db": "b"},
+		},
+		{
+			name: "empty section keeps the keys under it",
+			layers: []*Layer{
+				layer("a", "db.addr", "a:1"),
+				layer("b", "db", nil, "gormlog", true),
+			},
+			want: map[string]string{"db.addr": "a", "gormlog": "b"},
+		},
+		{
+			name: "empty section is kept when nothing is under it",
+			layers: []*Layer{
+				layer("a", "gormlog", false),
+				layer("b", "rbac", map[string]interface{}{}),
+			},
+			want: map[string]string{"gormlog": "a", "rbac": "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := make(map[string]string)
+		var keys []string
+		for _, s := range Merged(tt.layers) {
+			got[s.Key] = s.Origin.Source
+			keys = append(keys, s.Key)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Merged() = %v, want %v", tt.name, got, tt.want)
+		}
+		for i := 1; i < len(keys); i++ {
+			if keys[i-1] > keys[i] {
+				t.Errorf("%s: Merged() keys %v are not sorted", tt.name, keys)
+				break
+			}
+		}
+	}
+}
+
+func TestOverriddenBy(t *testing.T) {
+	a := layer("a", "db.addr", "a:1", "servers.0.name", "one", "servers.1.name", "two")
+	b := layer("b", "db.addr", "b:1", "servers.0.name", "three")
+	merged := Merged([]*Layer{a, b})
+
+	tests := []struct {
+		setting Setting
+		want    string
+		ok      bool
+	}{
+		{setting: a.Settings[0], want: "b", ok: true},
+		{setting: b.Settings[0], ok: false},
+		{setting: a.Settings[1], want: "b", ok: true},
+		// dropped by the shorter list of b
+		{setting: a.Settings[2], want: "b", ok: true},
+		{setting: b.Settings[1], ok: false},
+	}
+
+	for _, tt := range tests {
+		origin, ok := OverriddenBy(merged, tt.setting)
+		if ok != tt.ok || origin.Source != tt.want {
+			t.Errorf("OverriddenBy(%s of %s) = %v, %v, want %q, %v", tt.setting.Key, tt.setting.Origin.Source, origin, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// The prefixes of the secret references, which may be given in place of the
+// passwords of the config, such as `password: env:DB_PASSWORD`.
+const (
+	// SecretEnv refers to an environment variable.
+	SecretEnv = "env:"
+	// SecretFile refers to a file, the trailing newline is removed. It is
+	// only honoured in the trusted config files, see CheckSecretRef.
+	SecretFile = "file:"
+	// SecretExec refers to the stdout of a credential helper command. It is
+	// only honoured in the trusted config files, see CheckSecretRef.
+	SecretExec = "exec:"
+	// SecretStore refers to a secret set by `cmdctl secret set`.
+	SecretStore = "secret:"
+)
+
+// SecretPrefixes are the prefixes of the secret references.
+var SecretPrefixes = []string{SecretEnv, SecretFile, SecretExec, SecretStore}
+
+// IsSecretRef reports whether the value refers to a secret instead of
+// being the secret.
+func IsSecretRef(v string) bool {
+	for _, prefix := range SecretPrefixes {
+		if strings.HasPrefix(v, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecretKey reports whether the dotted key holds a secret, such as
+// db.password or credentials.0.credential.password.
+func IsSecretKey(key string) bool {
+	return key == "password" || strings.HasSuffix(key, ".password")
+}
+
+// TrustedScope reports whether the files of the scope may set the exec: and
+// file: references. The project files come with the directory cmdctl is run
+// in, such as a cloned repository, so neither they nor the included files
+// may run commands or read files.
+func TrustedScope(scope string) bool {
+	return scope == ScopeSystem || scope == ScopeUser || scope == ScopeFlag
+}
+
+// TrustedOrigin reports whether the setting in effect for the key is set by a
+// file of a trusted scope, the environment variables do not count as they may
+// be set by a script of the project.
+func TrustedOrigin(key string) (bool, error) {
+	layers, err := Layers()
+	if err != nil {
+		return false, err
+	}
+	for _, s := range Merged(layers) {
+		if s.Key != key {
+			continue
+		}
+		if s.Origin.Kind != OriginFile {
+			return false, nil
+		}
+		for _, source := range Sources() {
+			if source.Path == s.Origin.Source {
+				return TrustedScope(source.Scope), nil
+			}
+		}
+		return false, nil
+	}
+	return false, nil
+}
+
+// CheckSecretRef returns an error if the value is an exec: or file: reference
+// which the config in effect takes from a file of an untrusted scope. The
+// other values, and those set by the environment, are always allowed.
+func CheckSecretRef(value string) error {
+	if !strings.HasPrefix(value, SecretExec) && !strings.HasPrefix(value, SecretFile) {
+		return nil
+	}
+
+	layers, err := Layers()
+	if err != nil {
+		return err
+	}
+	scopes := make(map[string]string)
+	for _, source := range Sources() {
+		scopes[source.Path] = source.Scope
+	}
+	for _, s := range Merged(layers) {
+		if v, ok := s.Value.(string); !ok || v != value || !IsSecretKey(s.Key) || s.Origin.Kind != OriginFile {
+			continue
+		}
+		if scope := scopes[s.Origin.Source]; !TrustedScope(scope) {
+			return fmt.Errorf("%s of %s is set by the %s file %s, the exec: and file: references are only honoured in the system, user or --config file",
+				value, s.Key, scope, s.Origin)
+		}
+	}
+	return nil
+}
+
+// PlaintextSecrets returns the keys of the config file whose values are
+// secrets instead of secret references.
+func PlaintextSecrets(path string) ([]string, error) {
+	layer, err := fileLayer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, s := range layer.Settings {
+		if v, ok := s.Value.(string); ok && v != "" && IsSecretKey(s.Key) && !IsSecretRef(v) {
+			keys = append(keys, s.Key)
+		}
+	}
+	return keys, nil
+}
+
+// CheckPermissions returns a warning for every config file read which holds
+// plaintext secrets and is readable by the group or the others.
+func CheckPermissions() []string {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	var warnings []string
+	for _, source := range Sources() {
+		info, err := os.Stat(source.Path)
+		if err != nil || info.Mode().Perm()&0077 == 0 {
+			continue
+		}
+		keys, err := PlaintextSecrets(source.Path)
+		if err != nil || len(keys) == 0 {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("config file %s is readable by group or others (%s) but holds the plaintext secrets %s, "+
+			"run 'chmod 600 %s' or replace them by env:, file:, exec: or secret: references",
+			source.Path, info.Mode().Perm(), strings.Join(keys, ", "), source.Path))
+	}
+	return warnings
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestTrustedOrigin(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		FileName:        "include: project.yaml\nauth:\n  user: alice\n",
+		"project.yaml":  "auth:\n  user: admin\ndb:\n  name: cmdctl\n",
+		"override.yaml": "include: project.yaml\n",
+	})
+	defer os.RemoveAll(dir)
+	defer viper.Reset()
+
+	tests := []struct {
+		file string
+		env  string
+		want bool
+	}{
+		{file: FileName, want: true},
+		{file: "override.yaml", want: false},
+		{file: FileName, env: "admin", want: false},
+	}
+
+	for _, tt := range tests {
+		viper.Reset()
+		if err := ReadInConfig(filepath.Join(dir, tt.file), ""); err != nil {
+			t.Fatal(err)
+		}
+		os.Unsetenv("CMDCTL_AUTH_USER")
+		if tt.env != "" {
+			os.Setenv("CMDCTL_AUTH_USER", tt.env)
+		}
+		trusted, err := TrustedOrigin("auth.user")
+		os.Unsetenv("CMDCTL_AUTH_USER")
+		if err != nil || trusted != tt.want {
+			t.Errorf("TrustedOrigin(auth.user) of %s with env %q = %v, %v, want %v", tt.file, tt.env, trusted, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cmdctl/pkg/validation"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a mistake in a config file.
+type Problem struct {
+	File string
+	// Line and Column locate the key or the value, they start at 1.
+	Line   int
+	Column int
+	// Key is the dotted key, such as fileserver.timeout or contexts.0.name.
+	Key     string
+	Message string
+	// Hint tells how to fix the problem.
+	Hint string
+}
+
+func (p *Problem) String() string {
+	if p.Key == "" {
+		return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.File, p.Line, p.Column, p.Key, p.Message)
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	// yamlErrorLine finds the line in the errors of the yaml parser, such as
+	// "yaml: line 3: mapping values are not allowed in this context".
+	yamlErrorLine = regexp.MustCompile(`line (\d+): `)
+)
+
+// ValidateFile checks the config file against Config: the unknown keys, the
+// values of the wrong type, the values breaking the `validate` rules and the
+// contexts referring to missing servers, databases or credentials. The
+// problems are sorted by their lines.
+func ValidateFile(path string) ([]*Problem, error) {
+	return ValidateFiles([]string{path})
+}
+
+// ValidateFiles checks the files like ValidateFile, the contexts may refer
+// to the entries of the other files, which are merged in the order of the
+// paths. The problems are sorted by file and by line.
+func ValidateFiles(paths []string) ([]*Problem, error) {
+	return ValidateContent(paths, nil)
+}
+
+// ValidateContent checks the files like ValidateFiles, the content of the
+// paths in contents is checked instead of the file, such as the copy of a
+// file being edited.
+func ValidateContent(paths []string, contents map[string][]byte) ([]*Problem, error) {
+	v := &validator{}
+	var roots []fileRoot
+	for _, path := range paths {
+		data, ok := contents[path]
+		if !ok {
+			var err error
+			if data, err = ioutil.ReadFile(path); err != nil {
+				return nil, err
+			}
+		}
+
+		v.file = path
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			line := 1
+			if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+				line, _ = strconv.Atoi(m[1])
+			}
+			message := strings.TrimPrefix(yamlErrorLine.ReplaceAllString(err.Error(), ""), "yaml: ")
+			v.problems = append(v.problems, &Problem{
+				File:    path,
+				Line:    line,
+				Column:  1,
+				Message: message,
+				Hint:    "check the indentation and the quotes of the line, the keys are indented with spaces",
+			})
+			continue
+		}
+		if len(doc.Content) == 0 {
+			// an empty file uses the defaults
+			continue
+		}
+
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			v.add(root, "", "the config must be a mapping", "write the settings as `key: value` lines, such as `db:`")
+			continue
+		}
+		if include := value(root, IncludeKey); include != nil {
+			v.include(include)
+		}
+		v.walk(root, reflect.TypeOf(Config{}), "")
+		roots = append(roots, fileRoot{path: path, root: root})
+	}
+	v.references(roots)
+
+	order := make(map[string]int, len(paths))
+	for i, path := range paths {
+		order[path] = i
+	}
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		a, b := v.problems[i], v.problems[j]
+		if a.File != b.File {
+			return order[a.File] < order[b.File]
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.problems, nil
+}
+
+// ValidateSetting checks a setting before `cmdctl config set` writes it: the
+// dotted key against Config, and the value, parsed as yaml like File.Set,
+// against the type and the `validate` rules of its field.
+func ValidateSetting(key, v string) error {
+	if key == IncludeKey || strings.HasPrefix(key, IncludeKey+".") {
+		return nil
+	}
+
+	t := reflect.TypeOf(Config{})
+	var parent reflect.Type
+	segments := strings.Split(key, ".")
+	for i, segment := range segments {
+		prefix := strings.Join(segments[:i], ".")
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByKey(t, segment)
+			if !ok {
+				return fmt.Errorf("unknown key %s, %s", joinKey(prefix, segment), unknownKeyHint(t, prefix, segment))
+			}
+			parent, t = t, field.Type
+		case reflect.Map:
+			parent, t = nil, t.Elem()
+		case reflect.Slice:
+			if _, err := strconv.Atoi(segment); err != nil {
+				return fmt.Errorf("invalid key %s: %s is a list, use the index of an item such as %s.0", key, prefix, prefix)
+			}
+			parent, t = nil, t.Elem()
+		default:
+			return fmt.Errorf("invalid key %s: %s is not a mapping", key, prefix)
+		}
+	}
+
+	check := &validator{}
+	node := parseValue(v)
+	if parent != nil {
+		// the `validate` rules are those of the struct holding the field
+		last := segments[len(segments)-1]
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{scalar(last), node}}
+		check.walkStruct(mapping, parent, strings.Join(segments[:len(segments)-1], "."))
+	} else {
+		check.walk(node, t, key)
+	}
+
+	if len(check.problems) > 0 {
+		p := check.problems[0]
+		if p.Hint == "" {
+			return fmt.Errorf("invalid value for %s: %s", p.Key, p.Message)
+		}
+		return fmt.Errorf("invalid value for %s: %s, %s", p.Key, p.Message, p.Hint)
+	}
+	return nil
+}
+
+type fileRoot struct {
+	path string
+	root *yaml.Node
+}
+
+type validator struct {
+	file     string
+	problems []*Problem
+}
+
+func (v *validator) add(node *yaml.Node, key, message, hint string) {
+	v.problems = append(v.problems, &Problem{
+		File:    v.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Key:     key,
+		Message: message,
+		Hint:    hint,
+	})
+}
+
+// walk checks the node against the type, it returns false if the node is
+// not of the type.
+func (v *validator) walk(node *yaml.Node, t reflect.Type, key string) bool {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return true
+	}
+
+	switch {
+	case t == durationType:
+		if node.Kind == yaml.ScalarNode {
+			if node.Tag == "!!int" {
+				return true
+			}
+			if _, err := time.ParseDuration(node.Value); err == nil {
+				return true
+			}
+		}
+		v.add(node, key, fmt.Sprintf("%s is not a duration", describe(node)), "use a number with a unit, such as 30s, 5m or 1h")
+	case t.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.add(node, key, fmt.Sprintf("%s is not a mapping", describe(node)), fmt.Sprintf("indent the keys of %s under it", key))
+			return false
+		}
+		v.walkStruct(node, t, key)
+		return true
+	case t.Kind() == reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.add(node, key, fmt.Sprintf("%s is not a mapping", describe(node)), fmt.Sprintf("indent the named entries of %s under it", key))
+			return false
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.walk(node.Content[i+1], t.Elem(), joinKey(key, node.Content[i].Value))
+		}
+		return true
+	case t.Kind() == reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.add(node, key, fmt.Sprintf("%s is not a list", describe(node)), "start every item of the list with \"- \"")
+			return false
+		}
+		for i, item := range node.Content {
+			v.walk(item, t.Elem(), joinKey(key, strconv.Itoa(i)))
+		}
+		return true
+	case t.Kind() == reflect.Bool:
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!bool" {
+			return true
+		}
+		v.add(node, key, fmt.Sprintf("%s is not a boolean", describe(node)), "use true or false without quotes")
+	case t.Kind() == reflect.Int:
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!int" {
+			return true
+		}
+		v.add(node, key, fmt.Sprintf("%s is not an integer", describe(node)), "use a whole number without quotes")
+	case t.Kind() == reflect.String:
+		if node.Kind == yaml.ScalarNode {
+			return true
+		}
+		v.add(node, key, fmt.Sprintf("%s is not a string", describe(node)), "write the value on the line of the key, quote it if it has a colon")
+	}
+	return false
+}
+
+// walkStruct checks the keys of the mapping, and the values of the scalar
+// fields against their `validate` rules.
+func (v *validator) walkStruct(node *yaml.Node, t reflect.Type, key string) {
+	value := reflect.New(t)
+	nodes := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, val := node.Content[i], node.Content[i+1]
+		if key == "" && k.Value == IncludeKey {
+			// checked by include
+			continue
+		}
+		field, ok := fieldByKey(t, k.Value)
+		if !ok {
+			v.add(k, joinKey(key, k.Value), "unknown key", unknownKeyHint(t, key, k.Value))
+			continue
+		}
+		if !v.walk(val, field.Type, joinKey(key, k.Value)) {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice:
+		default:
+			nodes[jsonName(field)] = val
+			if field.Type == durationType && val.Tag != "!!int" {
+				d, _ := time.ParseDuration(val.Value)
+				value.Elem().FieldByIndex(field.Index).SetInt(int64(d))
+			} else {
+				val.Decode(value.Elem().FieldByIndex(field.Index).Addr().Interface())
+			}
+		}
+	}
+
+	err := validation.Validate(value.Interface())
+	if errs, ok := err.(validation.Errors); ok {
+		for _, e := range errs {
+			// the missing keys are not checked, they use the defaults
+			if val, ok := nodes[e.Field]; ok {
+				v.add(val, joinKey(key, e.Field), e.Message, ruleHint(e.Rule))
+			}
+		}
+	}
+}
+
+// include checks that the include directive is a path or a list of paths,
+// and that the files exist.
+func (v *validator) include(node *yaml.Node) {
+	items := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		items = node.Content
+	}
+	for i, item := range items {
+		key := IncludeKey
+		if node.Kind == yaml.SequenceNode {
+			key = joinKey(IncludeKey, strconv.Itoa(i))
+		}
+		if item.Kind != yaml.ScalarNode || item.Tag == "!!null" {
+			v.add(item, key, fmt.Sprintf("%s is not a path", describe(item)), "use a path or a list of paths, relative to this file")
+			continue
+		}
+
+		path := resolveInclude(v.file, item.Value)
+		if strings.ContainsAny(path, "*?[") {
+			if _, err := filepath.Glob(path); err != nil {
+				v.add(item, key, fmt.Sprintf("invalid pattern: %v", err), "use a pattern such as conf.d/*.yaml")
+			}
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			v.add(item, key, fmt.Sprintf("included file %s not found", path), "create the file, or fix the path relative to this file")
+		}
+	}
+}
+
+// references checks that current-context and the contexts refer to the
+// named entries of the config, and that the names are unique. A list of a
+// file replaces the lists of the files before it, as viper merges them.
+func (v *validator) references(roots []fileRoot) {
+	lists := make(map[string]fileRoot)
+	names := make(map[string][]string)
+	for _, list := range []string{"servers", "databases", "credentials", "contexts"} {
+		for _, r := range roots {
+			if items := value(r.root, list); items != nil && items.Kind == yaml.SequenceNode {
+				lists[list] = r
+			}
+		}
+		r, ok := lists[list]
+		if !ok {
+			continue
+		}
+
+		v.file = r.path
+		seen := make(map[string]bool)
+		for i, item := range value(r.root, list).Content {
+			key := joinKey(list, strconv.Itoa(i))
+			name := value(item, "name")
+			if name == nil || name.Value == "" {
+				v.add(item, key, "the name is missing", "add a `name:` key to the item, the contexts refer to it by name")
+				continue
+			}
+			if seen[name.Value] {
+				v.add(name, key+".name", fmt.Sprintf("duplicate name %q", name.Value), "rename or remove one of the items, only the first one is used")
+				continue
+			}
+			seen[name.Value] = true
+			names[list] = append(names[list], name.Value)
+		}
+	}
+
+	for _, r := range roots {
+		if current := value(r.root, "current-context"); current != nil && current.Value != "" {
+			v.file = r.path
+			v.reference(current, "current-context", "contexts", names["contexts"])
+		}
+	}
+	if r, ok := lists["contexts"]; ok {
+		v.file = r.path
+		for i, item := range value(r.root, "contexts").Content {
+			context := value(item, "context")
+			if context == nil {
+				continue
+			}
+			for _, ref := range []struct{ key, list string }{
+				{"server", "servers"},
+				{"database", "databases"},
+				{"credential", "credentials"},
+			} {
+				if n := value(context, ref.key); n != nil && n.Kind == yaml.ScalarNode && n.Value != "" {
+					v.reference(n, joinKey("contexts", strconv.Itoa(i), "context", ref.key), ref.list, names[ref.list])
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) reference(node *yaml.Node, key, list string, names []string) {
+	for _, name := range names {
+		if name == node.Value {
+			return
+		}
+	}
+
+	singular := strings.TrimSuffix(list, "s")
+	hint := fmt.Sprintf("add a %s named %q to %s", singular, node.Value, list)
+	if len(names) > 0 {
+		hint += ", or use one of: " + strings.Join(names, ", ")
+	}
+	v.add(node, key, fmt.Sprintf("%s %q not found", singular, node.Value), hint)
+}
+
+// fieldByKey returns the field of the key, the keys are case insensitive as
+// they are for viper.
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.EqualFold(field.Tag.Get("mapstructure"), key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func jsonName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// unknownKeyHint suggests the closest key, or else lists the keys.
+func unknownKeyHint(t reflect.Type, parent, key string) string {
+	keys := make([]string, 0, t.NumField())
+	best, bestDistance := "", -1
+	for i := 0; i < t.NumField(); i++ {
+		k := t.Field(i).Tag.Get("mapstructure")
+		keys = append(keys, k)
+		if d := levenshtein(strings.ToLower(key), k); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+
+	if bestDistance >= 0 && bestDistance <= len(best)/3+1 {
+		return fmt.Sprintf("did you mean %q?", best)
+	}
+	if parent == "" {
+		return "remove it, the top-level keys are: " + strings.Join(keys, ", ")
+	}
+	return fmt.Sprintf("remove it, the keys of %s are: %s", parent, strings.Join(keys, ", "))
+}
+
+func ruleHint(rule string) string {
+	switch rule {
+	case "oneof":
+		return "use one of the listed values"
+	case "min", "max":
+		return "change the value to fit the limit, or remove the key to use the default"
+	}
+	return ""
+}
+
+// describe quotes the value of a scalar node in the problems.
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return strconv.Quote(node.Value)
+	case yaml.MappingNode:
+		return "the mapping"
+	case yaml.SequenceNode:
+		return "the list"
+	}
+	return "the value"
+}
+
+func joinKey(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, ".")
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		row := make([]int, len(b)+1)
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min3(previous[j]+1, row[j-1]+1, previous[j-1]+cost)
+		}
+		previous = row
+	}
+	return previous[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the files into a temp dir and returns its path.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "cmdctl-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// want are the problems as "line:key: message"
+		want []string
+	}{
+		{name: "empty"},
+		{name: "valid", content: `
+db:
+  driver: mysql
+  addr: 127.0.0.1:3306
+  conn_max_lifetime: 5m
+password:
+  cost: 10
+servers:
+- name: local
+  server:
+    server: http://127.0.0.1
+contexts:
+- name: dev
+  context:
+    server: local
+current-context: dev
+`},
+		{name: "durations", content: `
+db:
+  conn_max_lifetime: 90
+datasources:
+  reports:
+    conn_max_lifetime: 1h30m
+  audit:
+    conn_max_lifetime: 5 minutes
+`, want: []string{`8:datasources.audit.conn_max_lifetime: "5 minutes" is not a duration`}},
+		{name: "unknown keys", content: `
+db:
+  adress: 127.0.0.1
+gromlog: true
+`, want: []string{"3:db.adress: unknown key", "4:gromlog: unknown key"}},
+		{name: "types", content: `
+gormlog: "yes"
+fileserver:
+  timeout: 5s
+rbac: true
+servers:
+  name: local
+`, want: []string{
+			`2:gormlog: "yes" is not a boolean`,
+			`4:fileserver.timeout: "5s" is not an integer`,
+			`5:rbac: "true" is not a mapping`,
+			"7:servers: the mapping is not a list",
+		}},
+		{name: "rules", content: `
+db:
+  driver: oracle
+  max_open_conns: -1
+password:
+  cost: 32
+`, want: []string{
+			`3:db.driver: "oracle" is invalid, must be one of 'mysql', 'postgres', 'sqlite3' or 'memory'`,
+			"4:db.max_open_conns: must be at least 0",
+			"6:password.cost: must be at most 31",
+		}},
+		{name: "references", content: `
+servers:
+- name: local
+- name: local
+contexts:
+- name: dev
+  context:
+    server: remote
+    database: main
+current-context: prod
+`, want: []string{
+			`4:servers.1.name: duplicate name "local"`,
+			`8:contexts.0.context.server: server "remote" not found`,
+			`9:contexts.0.context.database: database "main" not found`,
+			`10:current-context: context "prod" not found`,
+		}},
+		{name: "missing include", content: `
+include: [missing.yaml]
+`, want: []string{"2:include.0: included file"}},
+		{name: "invalid yaml", content: "db:\n  addr: [\n", want: []string{"2:"}},
+	}
+
+	for _, tt := range tests {
+		dir := writeFiles(t, map[string]string{FileName: tt.content})
+		problems, err := ValidateFile(filepath.Join(dir, FileName))
+		os.RemoveAll(dir)
+		if err != nil {
+			t.Errorf("%s: ValidateFile() returned error: %v", tt.name, err)
+			continue
+		}
+
+		if len(problems) != len(tt.want) {
+			t.Errorf("%s: ValidateFile() = %v, want %d problems", tt.name, problems, len(tt.want))
+			continue
+		}
+		for i, p := range problems {
+			got := fmt.Sprintf("%d:%s: %s", p.Line, p.Key, p.Message)
+			if p.Key == "" {
+				got = fmt.Sprintf("%d:%s", p.Line, p.Message)
+			}
+			if !strings.HasPrefix(got, tt.want[i]) {
+				t.Errorf("%s: problem %d = %q, want %q", tt.name, i, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestValidateFilesReferences(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"system.yaml": "servers:\n- name: local\n",
+		"user.yaml":   "contexts:\n- name: dev\n  context:\n    server: local\ncurrent-context: dev\n",
+	})
+	defer os.RemoveAll(dir)
+
+	problems, err := ValidateFiles([]string{filepath.Join(dir, "system.yaml"), filepath.Join(dir, "user.yaml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("ValidateFiles() = %v, want no problems", problems)
+	}
+}
+
+func TestValidateSetting(t *testing.T) {
+	tests := []struct {
+		key, value string
+		// wantErr is a part of the error, or empty if the setting is valid
+		wantErr string
+	}{
+		{key: "db.addr", value: "127.0.0.1:3306"},
+		{key: "DB.Addr", value: "127.0.0.1:3306"},
+		{key: "gormlog", value: "true"},
+		{key: "password.cost", value: "12"},
+		{key: "db.conn_max_lifetime", value: "1h"},
+		{key: "db.conn_max_lifetime", value: "60"},
+		{key: "datasources.reports.driver", value: "postgres"},
+		{key: "servers.0.server.timeout", value: "5"},
+		{key: "include", value: "[conf.d/*.yaml]"},
+		{key: "db", value: "{addr: 127.0.0.1}"},
+		{key: "db.adr", value: "127.0.0.1", wantErr: `unknown key db.adr, did you mean "addr"?`},
+		{key: "rbca", value: "x", wantErr: "unknown key rbca"},
+		{key: "db.addr.host", value: "x", wantErr: "db.addr is not a mapping"},
+		{key: "servers.first.name", value: "x", wantErr: "servers is a list"},
+		{key: "gormlog", value: "yes", wantErr: `"yes" is not a boolean`},
+		{key: "password.cost", value: "3", wantErr: "must be at least 4"},
+		{key: "db.driver", value: "oracle", wantErr: `"oracle" is invalid`},
+		{key: "db.conn_max_lifetime", value: "forever", wantErr: "is not a duration"},
+		{key: "datasources.reports.max_open_conns", value: "-1", wantErr: "must be at least 0"},
+		{key: "db", value: "{adress: 127.0.0.1}", wantErr: "unknown key"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSetting(tt.key, tt.value)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateSetting(%q, %q) = %v, want no error", tt.key, tt.value, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateSetting(%q, %q) = %v, want an error containing %q", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateContent(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"system.yaml": "servers:\n- name: local\n",
+		"user.yaml":   "current-context: \"\"\n",
+	})
+	defer os.RemoveAll(dir)
+
+	user := filepath.Join(dir, "user.yaml")
+	paths := []string{filepath.Join(dir, "system.yaml"), user}
+	edited := "contexts:\n- name: dev\n  context:\n    server: remote\ncurrent-context: dev\n"
+	problems, err := ValidateContent(paths, map[string][]byte{user: []byte(edited)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].File != user || problems[0].Line != 4 {
+		t.Errorf("ValidateContent() = %v, want the unknown server of %s on line 4", problems, user)
+	}
+}
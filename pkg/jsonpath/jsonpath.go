@@ -0,0 +1,400 @@
+// Package jsonpath implements the subset of the kubectl JSONPath templates
+// used by the -o jsonpath and -o custom-columns output of cmdctl.
+//
+// A template is text with expressions in braces:
+//
+//	{.username}                  a field of the current object
+//	{.items[0].name}             an element of an array
+//	{.items[*].name}             all the elements, printed separated by spaces
+//	{$.name}                     a field of the root object
+//	{range .items[*]}...{end}    the text in between for every element
+//	{"\n"}                       a quoted string literal
+//
+// The data must be made of the values produced by encoding/json, such as
+// map[string]interface{} and []interface{}.
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a parsed template.
+type JSONPath struct {
+	name  string
+	nodes []node
+}
+
+type node interface{}
+
+type textNode string
+
+type fieldNode struct {
+	path *Path
+}
+
+type rangeNode struct {
+	path  *Path
+	nodes []node
+}
+
+// Parse parses the template, the name is used in the error messages.
+func Parse(name, text string) (*JSONPath, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	nodes, rest, err := parseNodes(tokens, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%s: unexpected {end}", name)
+	}
+	return &JSONPath{name: name, nodes: nodes}, nil
+}
+
+// Execute writes the template applied to the data.
+func (j *JSONPath) Execute(w io.Writer, data interface{}) error {
+	var buf bytes.Buffer
+	if err := execute(&buf, j.nodes, data, data); err != nil {
+		return fmt.Errorf("%s: %v", j.name, err)
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func execute(w *bytes.Buffer, nodes []node, root, current interface{}) error {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case textNode:
+			w.WriteString(string(n))
+		case *fieldNode:
+			values, err := n.path.eval(root, current)
+			if err != nil {
+				return err
+			}
+			for i, v := range values {
+				if i > 0 {
+					w.WriteString(" ")
+				}
+				w.WriteString(Format(v))
+			}
+		case *rangeNode:
+			values, err := n.path.eval(root, current)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				if err := execute(w, n.nodes, root, v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Format formats a value found by a path, strings are printed as is and the
+// other values as json.
+func Format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+type token struct {
+	text string
+	expr bool
+}
+
+// lex splits the template into the text and the expressions in braces.
+func lex(text string) ([]token, error) {
+	tokens := make([]token, 0)
+	for len(text) > 0 {
+		start := strings.Index(text, "{")
+		if start < 0 {
+			tokens = append(tokens, token{text: text})
+			break
+		}
+		if start > 0 {
+			tokens = append(tokens, token{text: text[:start]})
+		}
+
+		end := closingBrace(text[start:])
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed expression %q", text[start:])
+		}
+		tokens = append(tokens, token{text: strings.TrimSpace(text[start+1 : start+end]), expr: true})
+		text = text[start+end+1:]
+	}
+	return tokens, nil
+}
+
+// closingBrace returns the index of the brace closing the expression at the
+// beginning of s, the braces in quoted strings are skipped.
+func closingBrace(s string) int {
+	quoted := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case '}':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseNodes(tokens []token, inRange bool) ([]node, []token, error) {
+	nodes := make([]node, 0)
+	for len(tokens) > 0 {
+		t := tokens[0]
+		tokens = tokens[1:]
+
+		if !t.expr {
+			nodes = append(nodes, textNode(t.text))
+			continue
+		}
+
+		switch {
+		case t.text == "end":
+			if !inRange {
+				return nil, nil, fmt.Errorf("unexpected {end}")
+			}
+			return nodes, append([]token{{text: "end", expr: true}}, tokens...), nil
+		case strings.HasPrefix(t.text, "range "):
+			path, err := ParsePath(strings.TrimSpace(strings.TrimPrefix(t.text, "range ")))
+			if err != nil {
+				return nil, nil, err
+			}
+			children, rest, err := parseNodes(tokens, true)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(rest) == 0 {
+				return nil, nil, fmt.Errorf("{range %s} is not closed by {end}", path)
+			}
+			nodes = append(nodes, &rangeNode{path: path, nodes: children})
+			tokens = rest[1:]
+		case strings.HasPrefix(t.text, `"`):
+			literal, err := strconv.Unquote(t.text)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid string literal %s", t.text)
+			}
+			nodes = append(nodes, textNode(literal))
+		default:
+			path, err := ParsePath(t.text)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, &fieldNode{path: path})
+		}
+	}
+
+	return nodes, nil, nil
+}
+
+// Path is a parsed path such as `.items[*].name`.
+type Path struct {
+	text string
+	// fromRoot is true if the path starts with $.
+	fromRoot bool
+	steps    []step
+}
+
+type step struct {
+	field string
+	index int
+	// all is true for [*] and .*
+	all bool
+	// isIndex is true for [n]
+	isIndex bool
+}
+
+func (s step) String() string {
+	switch {
+	case s.all:
+		return "[*]"
+	case s.isIndex:
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+	return "." + s.field
+}
+
+func (p *Path) String() string {
+	return p.text
+}
+
+// ParsePath parses a path, the leading dot may be omitted.
+func ParsePath(text string) (*Path, error) {
+	p := &Path{text: text}
+	s := text
+	switch {
+	case strings.HasPrefix(s, "$"):
+		p.fromRoot = true
+		s = s[1:]
+	case strings.HasPrefix(s, "@"):
+		s = s[1:]
+	case s != "" && s[0] != '.' && s[0] != '[':
+		s = "." + s
+	}
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			if s == "" {
+				break
+			}
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			field := s[:end]
+			s = s[end:]
+			switch field {
+			case "":
+				if len(s) == 0 || s[0] != '[' {
+					return nil, fmt.Errorf("invalid path %q: empty field name", text)
+				}
+			case "*":
+				p.steps = append(p.steps, step{all: true})
+			default:
+				p.steps = append(p.steps, step{field: field})
+			}
+		case '[':
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", text)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			if inner == "*" {
+				p.steps = append(p.steps, step{all: true})
+				continue
+			}
+			if unquoted, err := strconv.Unquote(strings.Replace(inner, "'", `"`, -1)); err == nil {
+				p.steps = append(p.steps, step{field: unquoted})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: invalid index [%s]", text, inner)
+			}
+			p.steps = append(p.steps, step{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid path %q at %q", text, s)
+		}
+	}
+	return p, nil
+}
+
+// NotFoundError is returned when a path does not exist in the data.
+type NotFoundError struct {
+	// Path is the part of the path which failed, such as `.items[0].name`.
+	Path   string
+	Reason string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s", e.Path, e.Reason)
+}
+
+// Evaluate returns the values found by the path in the data.
+func (p *Path) Evaluate(data interface{}) ([]interface{}, error) {
+	return p.eval(data, data)
+}
+
+func (p *Path) eval(root, current interface{}) ([]interface{}, error) {
+	values := []interface{}{current}
+	prefix := ""
+	if p.fromRoot {
+		values = []interface{}{root}
+		prefix = "$"
+	}
+
+	for _, s := range p.steps {
+		next := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			found, err := s.apply(v)
+			if err != nil {
+				return nil, &NotFoundError{Path: prefix + s.String(), Reason: err.Error()}
+			}
+			next = append(next, found...)
+		}
+		values = next
+		prefix += s.String()
+	}
+	return values, nil
+}
+
+func (s step) apply(v interface{}) ([]interface{}, error) {
+	switch {
+	case s.all:
+		switch v := v.(type) {
+		case []interface{}:
+			return v, nil
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			values := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				values = append(values, v[k])
+			}
+			return values, nil
+		}
+		return nil, fmt.Errorf("is not an array or object")
+	case s.isIndex:
+		array, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("is not an array")
+		}
+		index := s.index
+		if index < 0 {
+			index += len(array)
+		}
+		if index < 0 || index >= len(array) {
+			return nil, fmt.Errorf("is out of range, the array has %d elements", len(array))
+		}
+		return []interface{}{array[index]}, nil
+	}
+
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("is not found, the parent is not an object")
+	}
+	value, ok := object[s.field]
+	if !ok {
+		return nil, fmt.Errorf("is not found")
+	}
+	return []interface{}{value}, nil
+}
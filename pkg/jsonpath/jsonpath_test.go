@@ -0,0 +1,106 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+const data = `{
+	"name": "users",
+	"count": 2,
+	"enabled": true,
+	"items": [
+		{"username": "alice", "roles": ["admin", "user"], "attributes": {"team": "infra"}},
+		{"username": "bob", "roles": [], "attributes": null}
+	]
+}`
+
+func TestExecute(t *testing.T) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{template: "{.name}", want: "users"},
+		{template: "{ .count }/{.enabled}", want: "2/true"},
+		{template: "{.items[0].username}", want: "alice"},
+		{template: "{.items[-1].username}", want: "bob"},
+		{template: "{.items[*].username}", want: "alice bob"},
+		{template: "{.items[0].roles}", want: `["admin","user"]`},
+		{template: "{.items[0].attributes}", want: `{"team":"infra"}`},
+		{template: "{.items[0]['attributes'].team}", want: "infra"},
+		{template: "{.items[1].attributes}", want: ""},
+		{template: `{range .items[*]}{.username}{"\n"}{end}`, want: "alice\nbob\n"},
+		{template: `{range .items[*]}{.username}={$.name}{"}"}{end}`, want: "alice=users}bob=users}"},
+		{template: `{range .items[*]}{range .roles[*]}{@} {end}{end}`, want: "admin user "},
+		{template: "{.missing}", wantErr: true},
+		{template: "{.items[2].username}", wantErr: true},
+		{template: "{.name[0]}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		j, err := Parse("test", tt.template)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.template, err)
+			continue
+		}
+		var buf bytes.Buffer
+		err = j.Execute(&buf, v)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Execute(%q) = %q, want an error", tt.template, buf.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Execute(%q) returned error: %v", tt.template, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("Execute(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"{.name",
+		"{end}",
+		"{range .items[*]}{.username}",
+		`{"unterminated}`,
+		"{.items[a]}",
+		"{.items..name}",
+	}
+
+	for _, template := range tests {
+		if _, err := Parse("test", template); err == nil {
+			t.Errorf("Parse(%q) returned no error", template)
+		}
+	}
+}
+
+func TestNotFoundError(t *testing.T) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := ParsePath("$.items[5].username")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Evaluate(v)
+	nf, ok := err.(*NotFoundError)
+	if !ok {
+		t.Fatalf("Evaluate() = %v, want a NotFoundError", err)
+	}
+	if nf.Path != "$.items[5]" {
+		t.Errorf("NotFoundError.Path = %q, want %q", nf.Path, "$.items[5]")
+	}
+}
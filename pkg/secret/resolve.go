@@ -0,0 +1,90 @@
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"cmdctl/pkg/config"
+)
+
+// helpers caches the output of the credential helpers, which are run once
+// per process since they may prompt the user.
+var helpers sync.Map
+
+// Resolve returns the secret the value refers to, or the value itself if it
+// is not a reference:
+//
+//	env:VAR        the environment variable VAR
+//	file:PATH      the content of the file, without the trailing newline
+//	exec:COMMAND   the stdout of the command run by the shell, trimmed
+//	secret:NAME    the secret set by `cmdctl secret set NAME`
+//
+// The exec: and file: references set by the project or included config
+// files are rejected, see config.CheckSecretRef.
+func Resolve(value string) (string, error) {
+	if err := config.CheckSecretRef(value); err != nil {
+		return "", err
+	}
+
+	switch {
+	case strings.HasPrefix(value, config.SecretEnv):
+		name := strings.TrimPrefix(value, config.SecretEnv)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("error resolving %s: environment variable %s is not set", value, name)
+		}
+		return v, nil
+	case strings.HasPrefix(value, config.SecretFile):
+		path := strings.TrimPrefix(value, config.SecretFile)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error resolving %s: %v", value, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(value, config.SecretExec):
+		return runHelper(strings.TrimPrefix(value, config.SecretExec))
+	case strings.HasPrefix(value, config.SecretStore):
+		name := strings.TrimPrefix(value, config.SecretStore)
+		v, err := DefaultStore().Get(name)
+		if err == ErrNotFound {
+			return "", fmt.Errorf("error resolving %s: secret %q not found, set it by 'cmdctl secret set %s'", value, name, name)
+		}
+		if err != nil {
+			return "", fmt.Errorf("error resolving %s: %v", value, err)
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// runHelper runs the credential helper, its stdin and stderr are those of
+// cmdctl so that it may prompt the user.
+func runHelper(command string) (string, error) {
+	if v, ok := helpers.Load(command); ok {
+		return v.(string), nil
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("credential helper %q failed: %v", command, err)
+	}
+
+	v := strings.TrimSpace(stdout.String())
+	helpers.Store(command, v)
+	return v, nil
+}
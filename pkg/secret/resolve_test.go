@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"cmdctl/pkg/config"
+
+	"github.com/spf13/viper"
+)
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdctl-resolve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "password")
+	writeFile(t, file, "s3cret\r\n", 0600)
+	os.Setenv("CMDCTL_TEST_PASSWORD", "from env")
+	defer os.Unsetenv("CMDCTL_TEST_PASSWORD")
+	os.Unsetenv("CMDCTL_TEST_MISSING")
+
+	tests := []struct {
+		value string
+		want  string
+		// wantErr is a part of the error, or empty if it resolves
+		wantErr string
+	}{
+		{value: "plain", want: "plain"},
+		{value: "", want: ""},
+		{value: "Env:CMDCTL_TEST_PASSWORD", want: "Env:CMDCTL_TEST_PASSWORD"},
+		{value: "env:CMDCTL_TEST_PASSWORD", want: "from env"},
+		{value: "env:CMDCTL_TEST_MISSING", wantErr: "environment variable CMDCTL_TEST_MISSING is not set"},
+		{value: "file:" + file, want: "s3cret"},
+		{value: "file:" + filepath.Join(dir, "missing"), wantErr: "error resolving file:"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, []struct {
+			value   string
+			want    string
+			wantErr string
+		}{
+			{value: "exec:echo '  helper  '", want: "helper"},
+			{value: "exec:exit 3", wantErr: "credential helper \"exit 3\" failed"},
+		}...)
+	}
+
+	for _, tt := range tests {
+		got, err := Resolve(tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Resolve(%q) = %q, %v, want an error containing %q", tt.value, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveUntrustedRef(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdctl-resolve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer viper.Reset()
+
+	secretFile := filepath.Join(dir, "password")
+	writeFile(t, secretFile, "s3cret\n", 0600)
+	writeFile(t, filepath.Join(dir, "included.yaml"), "fileserver:\n  password: file:"+secretFile+"\n", 0600)
+
+	tests := []struct {
+		name string
+		// config is the file given by --config
+		config  string
+		wantErr bool
+	}{
+		{name: "set by the --config file", config: "db:\n  password: file:" + secretFile + "\n"},
+		{name: "set by an included file", config: "include: included.yaml\n", wantErr: true},
+		{name: "set by the --config file and an included file", config: "include: included.yaml\ndb:\n  password: file:" + secretFile + "\n", wantErr: true},
+		{name: "included file overridden", config: "include: included.yaml\nfileserver:\n  password: file:" + secretFile + "\n"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, "flag.yaml")
+		writeFile(t, path, tt.config, 0600)
+		viper.Reset()
+		if err := config.ReadInConfig(path, ""); err != nil {
+			t.Fatalf("%s: ReadInConfig() returned error: %v", tt.name, err)
+		}
+
+		got, err := Resolve("file:" + secretFile)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "only honoured in the system, user or --config file") {
+				t.Errorf("%s: Resolve() = %q, %v, want the reference to be rejected", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != "s3cret" {
+			t.Errorf("%s: Resolve() = %q, %v, want %q", tt.name, got, err, "s3cret")
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package secret resolves the secret references of the config, and keeps the
+// secrets set by `cmdctl secret set` in a file encrypted with AES-256-GCM by
+// a key file which is only readable by the user.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"cmdctl/pkg/config"
+	"cmdctl/pkg/homedir"
+)
+
+const (
+	// keySize is the size of the AES-256 keys.
+	keySize = 32
+	// magic starts the store files, followed by the version.
+	magic = "cmdctl-secrets:1\n"
+)
+
+// ErrNotFound is returned for the secrets which are not set.
+var ErrNotFound = errors.New("secret not found")
+
+// nameRegexp matches the valid secret names.
+var nameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Store is a file of named secrets, encrypted by the key of KeyPath.
+type Store struct {
+	Path    string
+	KeyPath string
+}
+
+// DefaultStore returns the store of the user, ~/.cmdctl/secrets encrypted by
+// ~/.cmdctl/secrets.key.
+func DefaultStore() *Store {
+	dir := filepath.Join(homedir.HomeDir(), ".cmdctl")
+	return &Store{Path: filepath.Join(dir, "secrets"), KeyPath: filepath.Join(dir, "secrets.key")}
+}
+
+// ValidateName checks the name of a secret.
+func ValidateName(name string) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q, must be letters, digits, '_', '.' or '-'", name)
+	}
+	return nil
+}
+
+// Get returns the secret of the name, or ErrNotFound.
+func (s *Store) Get(name string) (string, error) {
+	secrets, err := s.load(false)
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Set sets the secret of the name, the key is generated if it does not exist.
+func (s *Store) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	secrets, err := s.load(true)
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return s.save(secrets)
+}
+
+// Delete removes the secret of the name, it returns false if it is not set.
+func (s *Store) Delete(name string) (bool, error) {
+	secrets, err := s.load(false)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := secrets[name]; !ok {
+		return false, nil
+	}
+	delete(secrets, name)
+	return true, s.save(secrets)
+}
+
+// load decrypts the secrets, the store is empty if the file does not exist.
+// The key is generated if create is true and it does not exist.
+func (s *Store) load(create bool) (map[string]string, error) {
+	secrets := make(map[string]string)
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		if create {
+			if _, err := s.key(true); err != nil {
+				return nil, err
+			}
+		}
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(string(data), magic) {
+		return nil, fmt.Errorf("%s is not a cmdctl secrets file", s.Path)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data[len(magic):])))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", s.Path, err)
+	}
+
+	gcm, err := s.cipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s is truncated", s.Path)
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(magic))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting %s, it was not encrypted by %s", s.Path, s.KeyPath)
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", s.Path, err)
+	}
+	return secrets, nil
+}
+
+// save encrypts the secrets with a new nonce.
+func (s *Store) save(secrets map[string]string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := s.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := gcm.Seal(nonce, nonce, plain, []byte(magic))
+
+	data := magic + base64.StdEncoding.EncodeToString(sealed) + "\n"
+	return config.WriteFileAtomic(s.Path, []byte(data))
+}
+
+func (s *Store) cipher() (cipher.AEAD, error) {
+	key, err := s.key(false)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// key reads the key file, which must only be accessible by the user. It is
+// generated if create is true and it does not exist.
+func (s *Store) key(create bool) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.KeyPath)
+	if os.IsNotExist(err) && create {
+		key := make([]byte, keySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(s.KeyPath), 0700); err != nil {
+			return nil, err
+		}
+		if err := config.WriteFileAtomic(s.KeyPath, []byte(base64.StdEncoding.EncodeToString(key)+"\n")); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("secret key %s not found, the secrets can not be decrypted", s.KeyPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(s.KeyPath); err == nil && info.Mode().Perm()&0077 != 0 {
+			return nil, fmt.Errorf("secret key %s is accessible by group or others (%s), run 'chmod 600 %s'", s.KeyPath, info.Mode().Perm(), s.KeyPath)
+		}
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("invalid secret key %s", s.KeyPath)
+	}
+	return key, nil
+}
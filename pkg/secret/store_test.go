@@ -0,0 +1,173 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func tempStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "cmdctl-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Store{Path: filepath.Join(dir, "secrets"), KeyPath: filepath.Join(dir, "keys", "secrets.key")}
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	s, cleanup := tempStore(t)
+	defer cleanup()
+
+	if _, err := s.Get("db"); err != ErrNotFound {
+		t.Fatalf("Get() of an empty store = %v, want ErrNotFound", err)
+	}
+
+	secrets := map[string]string{
+		"db":           "p@ss:word",
+		"fileserver.1": "",
+		"multi-line":   "line1\nline2\n",
+		"unicode_key":  "密码",
+	}
+	for name, value := range secrets {
+		if err := s.Set(name, value); err != nil {
+			t.Fatalf("Set(%q) returned error: %v", name, err)
+		}
+	}
+	for name, want := range secrets {
+		got, err := s.Get(name)
+		if err != nil || got != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), magic) || strings.Contains(string(data), "p@ss:word") {
+		t.Errorf("store file is not encrypted: %q", data)
+	}
+	if runtime.GOOS != "windows" {
+		for _, path := range []string{s.Path, s.KeyPath} {
+			if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+				t.Errorf("mode of %s = %v, %v, want 0600", path, info.Mode().Perm(), err)
+			}
+		}
+	}
+
+	// a new nonce is used for every save
+	if err := s.Set("db", "p@ss:word"); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) == string(data) {
+		t.Errorf("the store was saved with the same nonce")
+	}
+
+	deleted, err := s.Delete("db")
+	if err != nil || !deleted {
+		t.Errorf("Delete(db) = %v, %v, want true", deleted, err)
+	}
+	if deleted, err := s.Delete("db"); err != nil || deleted {
+		t.Errorf("second Delete(db) = %v, %v, want false", deleted, err)
+	}
+	if _, err := s.Get("db"); err != ErrNotFound {
+		t.Errorf("Get(db) after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, s *Store)
+		// wantErr is a part of the error of Get
+		wantErr string
+	}{
+		{
+			name: "tampered",
+			modify: func(t *testing.T, s *Store) {
+				data, _ := ioutil.ReadFile(s.Path)
+				body := []byte(strings.TrimSpace(string(data[len(magic):])))
+				i := len(body) / 2
+				if body[i] == 'A' {
+					body[i] = 'B'
+				} else {
+					body[i] = 'A'
+				}
+				writeFile(t, s.Path, magic+string(body)+"\n", 0600)
+			},
+			wantErr: "error decrypting",
+		},
+		{
+			name: "other key",
+			modify: func(t *testing.T, s *Store) {
+				other := &Store{Path: s.Path + ".other", KeyPath: s.KeyPath + ".other"}
+				if err := other.Set("db", "secret"); err != nil {
+					t.Fatal(err)
+				}
+				data, _ := ioutil.ReadFile(other.Path)
+				writeFile(t, s.Path, string(data), 0600)
+			},
+			wantErr: "was not encrypted by",
+		},
+		{
+			name:    "not a store",
+			modify:  func(t *testing.T, s *Store) { writeFile(t, s.Path, "db: secret\n", 0600) },
+			wantErr: "is not a cmdctl secrets file",
+		},
+		{
+			name:    "truncated",
+			modify:  func(t *testing.T, s *Store) { writeFile(t, s.Path, magic+"AAAA\n", 0600) },
+			wantErr: "is truncated",
+		},
+		{
+			name:    "missing key",
+			modify:  func(t *testing.T, s *Store) { os.Remove(s.KeyPath) },
+			wantErr: "not found",
+		},
+		{
+			name:    "invalid key",
+			modify:  func(t *testing.T, s *Store) { writeFile(t, s.KeyPath, "c2hvcnQ=\n", 0600) },
+			wantErr: "invalid secret key",
+		},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			name    string
+			modify  func(t *testing.T, s *Store)
+			wantErr string
+		}{
+			name:    "key readable by others",
+			modify:  func(t *testing.T, s *Store) { os.Chmod(s.KeyPath, 0644) },
+			wantErr: "accessible by group or others",
+		})
+	}
+
+	for _, tt := range tests {
+		func() {
+			s, cleanup := tempStore(t)
+			defer cleanup()
+			if err := s.Set("db", "secret"); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.modify(t, s)
+			if _, err := s.Get("db"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: Get() = %v, want an error containing %q", tt.name, err, tt.wantErr)
+			}
+		}()
+	}
+}
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	if err := ioutil.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+}
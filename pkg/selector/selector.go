@@ -0,0 +1,205 @@
+// Package selector matches key/value attributes against selectors such as
+// `team=infra,tier!=oncall,env in (prod,staging)`.
+//
+// The requirements are separated by commas and must all match:
+//
+//	key=value, key==value  the attribute equals the value
+//	key!=value             the attribute is missing or differs from the value
+//	key in (v1,v2)         the attribute equals one of the values
+//	key notin (v1,v2)      the attribute is missing or equals none of the values
+//	key                    the attribute exists
+//	!key                   the attribute does not exist
+package selector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operator is the relation between the key and the values of a requirement.
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// keyRegexp is the format of an attribute key.
+var keyRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([-_./a-zA-Z0-9]*[a-zA-Z0-9])?$`)
+
+// setRegexp matches the `key in (v1,v2)` and `key notin (v1,v2)` requirements.
+var setRegexp = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// ValidateKey checks the key is a valid attribute key.
+func ValidateKey(key string) error {
+	if len(key) > 63 || !keyRegexp.MatchString(key) {
+		return fmt.Errorf("invalid attribute key %q, it must be at most 63 letters, digits, '-', '_', '.' or '/', and start and end with a letter or digit", key)
+	}
+	return nil
+}
+
+// Requirement is a condition on one attribute.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Matches reports whether the attributes meet the requirement.
+func (r Requirement) Matches(attrs map[string]string) bool {
+	value, ok := attrs[r.Key]
+	switch r.Operator {
+	case Equals:
+		return ok && value == r.Values[0]
+	case NotEquals:
+		return !ok || value != r.Values[0]
+	case In:
+		return ok && contains(r.Values, value)
+	case NotIn:
+		return !ok || !contains(r.Values, value)
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Equals, NotEquals:
+		return r.Key + string(r.Operator) + r.Values[0]
+	case In, NotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	case DoesNotExist:
+		return "!" + r.Key
+	}
+	return r.Key
+}
+
+// Selector is a set of requirements which must all match, an empty selector
+// matches everything.
+type Selector []Requirement
+
+// Matches reports whether the attributes meet all the requirements.
+func (s Selector) Matches(attrs map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty reports whether the selector matches everything.
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// Parse parses the selector, an empty string selects everything.
+func Parse(selector string) (Selector, error) {
+	parts, err := split(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	s := make(Selector, 0, len(parts))
+	for _, part := range parts {
+		r, err := parseRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+// split splits the selector at the commas outside of the parentheses.
+func split(selector string) ([]string, error) {
+	parts := make([]string, 0)
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unexpected ')' in selector %q", selector)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("missing ')' in selector %q", selector)
+	}
+	parts = append(parts, selector[start:])
+
+	trimmed := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			if len(parts) == 1 {
+				return trimmed, nil
+			}
+			return nil, fmt.Errorf("empty requirement in selector %q", selector)
+		}
+		trimmed = append(trimmed, part)
+	}
+	return trimmed, nil
+}
+
+func parseRequirement(part string) (Requirement, error) {
+	var r Requirement
+	if m := setRegexp.FindStringSubmatch(part); m != nil {
+		r = Requirement{Key: m[1], Operator: Operator(m[2])}
+		for _, v := range strings.Split(m[3], ",") {
+			r.Values = append(r.Values, strings.TrimSpace(v))
+		}
+	} else if strings.HasPrefix(part, "!") && !strings.Contains(part, "=") {
+		r = Requirement{Key: strings.TrimSpace(part[1:]), Operator: DoesNotExist}
+	} else if i := strings.Index(part, "!="); i >= 0 {
+		r = Requirement{Key: part[:i], Operator: NotEquals, Values: []string{part[i+2:]}}
+	} else if i := strings.Index(part, "=="); i >= 0 {
+		r = Requirement{Key: part[:i], Operator: Equals, Values: []string{part[i+2:]}}
+	} else if i := strings.Index(part, "="); i >= 0 {
+		r = Requirement{Key: part[:i], Operator: Equals, Values: []string{part[i+1:]}}
+	} else {
+		r = Requirement{Key: part, Operator: Exists}
+	}
+
+	r.Key = strings.TrimSpace(r.Key)
+	for i := range r.Values {
+		r.Values[i] = strings.TrimSpace(r.Values[i])
+	}
+	if err := ValidateKey(r.Key); err != nil {
+		return r, fmt.Errorf("invalid requirement %q: %v", part, err)
+	}
+	return r, nil
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
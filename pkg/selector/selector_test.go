@@ -0,0 +1,110 @@
+package selector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     Selector
+		wantErr  bool
+	}{
+		{selector: "", want: Selector{}},
+		{selector: "  ", want: Selector{}},
+		{selector: "team=infra", want: Selector{{Key: "team", Operator: Equals, Values: []string{"infra"}}}},
+		{selector: "team==infra", want: Selector{{Key: "team", Operator: Equals, Values: []string{"infra"}}}},
+		{selector: "tier != oncall", want: Selector{{Key: "tier", Operator: NotEquals, Values: []string{"oncall"}}}},
+		{selector: "team=", want: Selector{{Key: "team", Operator: Equals, Values: []string{""}}}},
+		{selector: "env in (prod, staging)", want: Selector{{Key: "env", Operator: In, Values: []string{"prod", "staging"}}}},
+		{selector: "env notin (dev)", want: Selector{{Key: "env", Operator: NotIn, Values: []string{"dev"}}}},
+		{selector: "team", want: Selector{{Key: "team", Operator: Exists}}},
+		{selector: "!team", want: Selector{{Key: "team", Operator: DoesNotExist}}},
+		{selector: "example.com/team=infra,env in (prod,staging),!oncall", want: Selector{
+			{Key: "example.com/team", Operator: Equals, Values: []string{"infra"}},
+			{Key: "env", Operator: In, Values: []string{"prod", "staging"}},
+			{Key: "oncall", Operator: DoesNotExist},
+		}},
+		{selector: "team=infra,", wantErr: true},
+		{selector: ",team=infra", wantErr: true},
+		{selector: "env in (prod", wantErr: true},
+		{selector: "env in prod)", wantErr: true},
+		{selector: "-team=infra", wantErr: true},
+		{selector: "team name=infra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.selector)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", tt.selector, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.selector, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	attrs := map[string]string{"team": "infra", "env": "prod"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{selector: "", want: true},
+		{selector: "team=infra", want: true},
+		{selector: "team=web", want: false},
+		{selector: "team!=web", want: true},
+		{selector: "tier!=oncall", want: true},
+		{selector: "env in (prod,staging)", want: true},
+		{selector: "tier in (prod,staging)", want: false},
+		{selector: "env notin (prod)", want: false},
+		{selector: "tier notin (prod)", want: true},
+		{selector: "team", want: true},
+		{selector: "tier", want: false},
+		{selector: "!tier", want: true},
+		{selector: "!team", want: false},
+		{selector: "team=infra,env=dev", want: false},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.selector)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", tt.selector, err)
+		}
+		if got := s.Matches(attrs); got != tt.want {
+			t.Errorf("%q.Matches(%v) = %v, want %v", tt.selector, attrs, got, tt.want)
+		}
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{key: "team", valid: true},
+		{key: "a", valid: true},
+		{key: "example.com/team_name-1", valid: true},
+		{key: "", valid: false},
+		{key: "-team", valid: false},
+		{key: "team.", valid: false},
+		{key: "team name", valid: false},
+		{key: strings.Repeat("a", 63), valid: true},
+		{key: strings.Repeat("a", 64), valid: false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateKey(tt.key); (err == nil) != tt.valid {
+			t.Errorf("ValidateKey(%q) = %v, want valid %v", tt.key, err, tt.valid)
+		}
+	}
+}
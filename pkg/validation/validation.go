@@ -0,0 +1,269 @@
+// Package validation checks struct fields against the rules in their
+// `validate` tags, such as `validate:"required,max=32,regexp=^[a-z]+$"`.
+//
+// The supported rules are:
+//
+//	required     the field must not be the zero value
+//	min=N, max=N the length of a string, slice or map, or the value of a number
+//	email        the string must be an email address
+//	oneof=a b c  the string must be one of the space separated values
+//	regexp=EXPR  the string must match the expression, it must be the last rule
+//
+// email, oneof and regexp accept the empty string, combine them with required
+// if the field can not be empty. The field is named after its `flag` tag as
+// --flag, its `json` tag, or its name with the first letter lower cased.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// FieldError is a field which breaks one of its rules.
+type FieldError struct {
+	// Field is the display name of the field.
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Errors are all the field errors of a struct, one per line.
+type Errors []*FieldError
+
+func (errs Errors) Error() string {
+	lines := make([]string, 0, len(errs))
+	for _, e := range errs {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks all the tagged fields of the struct v points to, including
+// those of the embedded structs. It returns Errors if any field is invalid.
+func Validate(v interface{}) error {
+	return validate(v, nil)
+}
+
+// ValidateFields is like Validate but only checks the fields with the names.
+func ValidateFields(v interface{}, names ...string) error {
+	only := make(map[string]bool, len(names))
+	for _, name := range names {
+		only[name] = true
+	}
+	return validate(v, only)
+}
+
+func validate(v interface{}, only map[string]bool) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: %T is not a struct", v))
+	}
+
+	var errs Errors
+	validateStruct(value, only, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(value reflect.Value, only map[string]bool, errs *Errors) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && value.Field(i).Kind() == reflect.Struct {
+			validateStruct(value.Field(i), only, errs)
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || (only != nil && !only[field.Name]) {
+			continue
+		}
+
+		name := fieldName(field)
+		for _, rule := range parseRules(tag) {
+			if msg := rule.check(value.Field(i)); msg != "" {
+				*errs = append(*errs, &FieldError{Field: name, Rule: rule.name, Message: msg})
+			}
+		}
+	}
+}
+
+func fieldName(field reflect.StructField) string {
+	if flag := field.Tag.Get("flag"); flag != "" {
+		return "--" + flag
+	}
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+
+	r, size := utf8.DecodeRuneInString(field.Name)
+	return string(unicode.ToLower(r)) + field.Name[size:]
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+var (
+	rulesCache sync.Map
+	regexps    sync.Map
+)
+
+// parseRules splits the tag into rules, everything after `regexp=` is the
+// expression so it may contain commas.
+func parseRules(tag string) []rule {
+	if cached, ok := rulesCache.Load(tag); ok {
+		return cached.([]rule)
+	}
+
+	rules := make([]rule, 0)
+	for rest := tag; rest != ""; {
+		var part string
+		if strings.HasPrefix(rest, "regexp=") {
+			part, rest = rest, ""
+		} else if i := strings.Index(rest, ","); i >= 0 {
+			part, rest = rest[:i], rest[i+1:]
+		} else {
+			part, rest = rest, ""
+		}
+
+		r := rule{name: part}
+		if i := strings.Index(part, "="); i >= 0 {
+			r.name, r.param = part[:i], part[i+1:]
+		}
+		switch r.name {
+		case "required", "email", "oneof":
+		case "min", "max":
+			if _, err := strconv.ParseFloat(r.param, 64); err != nil {
+				panic(fmt.Sprintf("validation: invalid %s rule in tag %q", r.name, tag))
+			}
+		case "regexp":
+			regexps.LoadOrStore(r.param, regexp.MustCompile(r.param))
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q in tag %q", r.name, tag))
+		}
+		rules = append(rules, r)
+	}
+
+	rulesCache.Store(tag, rules)
+	return rules
+}
+
+// check returns the message of the broken rule, or empty if the value is valid.
+func (r rule) check(value reflect.Value) string {
+	switch r.name {
+	case "required":
+		if isZero(value) {
+			return "is required"
+		}
+	case "min", "max":
+		return r.checkRange(value)
+	case "email":
+		if s := value.String(); s != "" && !govalidator.IsEmail(s) {
+			return fmt.Sprintf("%q is not a valid email address", s)
+		}
+	case "oneof":
+		values := strings.Fields(r.param)
+		if s := value.String(); s != "" && !contains(values, s) {
+			return fmt.Sprintf("%q is invalid, must be one of %s", s, quoteList(values))
+		}
+	case "regexp":
+		re, _ := regexps.Load(r.param)
+		if s := value.String(); s != "" && !re.(*regexp.Regexp).MatchString(s) {
+			return fmt.Sprintf("%q does not match %s", s, r.param)
+		}
+	}
+	return ""
+}
+
+func (r rule) checkRange(value reflect.Value) string {
+	limit, _ := strconv.ParseFloat(r.param, 64)
+	bound, unit := "at least", ""
+
+	var n float64
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		n, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		panic(fmt.Sprintf("validation: %s rule does not apply to %s", r.name, value.Kind()))
+	}
+
+	if r.name == "max" {
+		bound = "at most"
+		if n <= limit {
+			return ""
+		}
+	} else if n >= limit {
+		return ""
+	}
+
+	if unit != "" {
+		return fmt.Sprintf("must have %s %s%s", bound, r.param, unit)
+	}
+	return fmt.Sprintf("must be %s %s", bound, r.param)
+}
+
+func isZero(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	}
+	return false
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteList formats the values as 'a', 'b' or 'c'.
+func quoteList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, "'"+v+"'")
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+)
+
+type user struct {
+	Username string            `json:"username" validate:"required,max=8,regexp=^[a-z][a-z0-9,]*$"`
+	Email    string            `flag:"email" validate:"email"`
+	Role     string            `json:"role,omitempty" validate:"oneof=admin user"`
+	Cost     int               `validate:"min=4,max=31"`
+	Tags     []string          `json:"tags" validate:"max=2"`
+	Attrs    map[string]string `json:"-" validate:"required"`
+	Ignored  string
+}
+
+func TestValidate(t *testing.T) {
+	valid := user{Username: "alice", Email: "alice@example.com", Role: "admin", Cost: 10, Attrs: map[string]string{"team": "infra"}}
+
+	tests := []struct {
+		name   string
+		modify func(u *user)
+		want   []FieldError
+	}{
+		{name: "valid", modify: func(u *user) {}},
+		{name: "empty optional fields", modify: func(u *user) { u.Email, u.Role = "", "" }},
+		{name: "comma in regexp", modify: func(u *user) { u.Username = "a,b" }},
+		{name: "required", modify: func(u *user) { u.Username = "" }, want: []FieldError{
+			{Field: "username", Rule: "required", Message: "is required"},
+		}},
+		{name: "string too long and not matching", modify: func(u *user) { u.Username = "Alice_Smith" }, want: []FieldError{
+			{Field: "username", Rule: "max", Message: "must have at most 8 characters"},
+			{Field: "username", Rule: "regexp", Message: `"Alice_Smith" does not match ^[a-z][a-z0-9,]*$`},
+		}},
+		{name: "email named after the flag", modify: func(u *user) { u.Email = "alice" }, want: []FieldError{
+			{Field: "--email", Rule: "email", Message: `"alice" is not a valid email address`},
+		}},
+		{name: "oneof", modify: func(u *user) { u.Role = "root" }, want: []FieldError{
+			{Field: "role", Rule: "oneof", Message: `"root" is invalid, must be one of 'admin' or 'user'`},
+		}},
+		{name: "number below min", modify: func(u *user) { u.Cost = 3 }, want: []FieldError{
+			{Field: "cost", Rule: "min", Message: "must be at least 4"},
+		}},
+		{name: "number at the limits", modify: func(u *user) { u.Cost = 31 }},
+		{name: "too many items", modify: func(u *user) { u.Tags = []string{"a", "b", "c"} }, want: []FieldError{
+			{Field: "tags", Rule: "max", Message: "must have at most 2 items"},
+		}},
+		{name: "json name -", modify: func(u *user) { u.Attrs = nil }, want: []FieldError{
+			{Field: "attrs", Rule: "required", Message: "is required"},
+		}},
+	}
+
+	for _, tt := range tests {
+		u := valid
+		tt.modify(&u)
+		err := Validate(&u)
+		if len(tt.want) == 0 {
+			if err != nil {
+				t.Errorf("%s: Validate() = %v, want no error", tt.name, err)
+			}
+			continue
+		}
+
+		errs, ok := err.(Errors)
+		if !ok {
+			t.Errorf("%s: Validate() = %v, want Errors", tt.name, err)
+			continue
+		}
+		got := make([]FieldError, 0, len(errs))
+		for _, e := range errs {
+			got = append(got, *e)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Validate() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	u := user{Cost: 1}
+	err := ValidateFields(&u, "Cost")
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 || errs[0].Field != "cost" {
+		t.Errorf("ValidateFields(Cost) = %v, want only the error of cost", err)
+	}
+	if err := ValidateFields(&u, "Role"); err != nil {
+		t.Errorf("ValidateFields(Role) = %v, want no error", err)
+	}
+}
+
+type base struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type embedding struct {
+	base
+	Size int `json:"size" validate:"min=1"`
+}
+
+func TestValidateEmbedded(t *testing.T) {
+	err := Validate(&embedding{})
+	if want := "name is required\nsize must be at least 1"; err == nil || err.Error() != want {
+		t.Errorf("Validate() = %v, want %q", err, want)
+	}
+}